@@ -0,0 +1,784 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const libPath = "github.com/xxbtwxx/desensitivize"
+
+type config struct {
+	dir       string
+	output    string
+	typeNames []string
+	tagNames  []string
+}
+
+type generator struct {
+	cfg     config
+	pkg     *types.Package
+	imports map[string]string
+	targets map[*types.Named]bool
+	vars    int
+}
+
+func generate(cfg config) ([]byte, error) {
+	pkg, err := loadPackage(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		cfg:     cfg,
+		pkg:     pkg,
+		imports: map[string]string{},
+		targets: map[*types.Named]bool{},
+	}
+
+	if err := g.collectTargets(); err != nil {
+		return nil, err
+	}
+
+	targets := make([]*types.Named, 0, len(g.targets))
+	for named := range g.targets {
+		targets = append(targets, named)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Obj().Name() < targets[j].Obj().Name()
+	})
+
+	var body bytes.Buffer
+	for _, named := range targets {
+		g.writeRedact(&body, named)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by desensitivize-gen. DO NOT EDIT.\n\npackage %s\n\n", pkg.Name())
+
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(i, j int) bool {
+			if isStd(paths[i]) != isStd(paths[j]) {
+				return isStd(paths[i])
+			}
+			return paths[i] < paths[j]
+		})
+
+		// Standard library imports come first, separated from the others.
+		src.WriteString("import (\n")
+		for i, path := range paths {
+			if i > 0 && isStd(paths[i-1]) && !isStd(path) {
+				src.WriteString("\n")
+			}
+			fmt.Fprintf(&src, "\t%q\n", path)
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return formatted, nil
+}
+
+func isStd(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// loadPackage type checks the package in cfg.dir, leaving out the file
+// generated by a previous run.
+func loadPackage(cfg config) (*types.Package, error) {
+	matches, err := filepath.Glob(filepath.Join(cfg.dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+
+	for _, path := range matches {
+		name := filepath.Base(path)
+		if name == cfg.output || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		if match, err := build.Default.MatchFile(cfg.dir, name); err != nil || !match {
+			continue
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := parser.ParseFile(fset, path, src, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", cfg.dir)
+	}
+
+	imp, err := exportImporter(fset, cfg.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var typeErr error
+	conf := types.Config{
+		Importer: imp,
+		Error: func(err error) {
+			// The package may use the methods about to be generated.
+			if typeErr == nil && !missingRedact.MatchString(err.Error()) {
+				typeErr = err
+			}
+		},
+	}
+
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+	if typeErr != nil {
+		return nil, typeErr
+	}
+
+	return pkg, nil
+}
+
+var missingRedact = regexp.MustCompile(`\bmethod Redact\b`)
+
+// exportImporter imports the dependencies of the package in dir from the
+// export data the go command builds for them, which is much faster than
+// type-checking them from source.
+func exportImporter(fset *token.FileSet, dir string) (types.Importer, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "list", "-e", "-export", "-deps", "-json", ".")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	exports := map[string]string{}
+	importMap := map[string]string{}
+
+	dec := json.NewDecoder(&stdout)
+	for dec.More() {
+		var pkg struct {
+			ImportPath string
+			Export     string
+			ImportMap  map[string]string
+		}
+		if err := dec.Decode(&pkg); err != nil {
+			return nil, fmt.Errorf("go list: %v", err)
+		}
+
+		if pkg.Export != "" {
+			exports[pkg.ImportPath] = pkg.Export
+		}
+		for path, actual := range pkg.ImportMap {
+			importMap[path] = actual
+		}
+	}
+
+	return importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		if actual, exist := importMap[path]; exist {
+			path = actual
+		}
+
+		export, exist := exports[path]
+		if !exist {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+
+		return os.Open(export)
+	}), nil
+}
+
+func (g *generator) collectTargets() error {
+	var queue []*types.Named
+
+	if len(g.cfg.typeNames) == 0 {
+		scope := g.pkg.Scope()
+		for _, name := range scope.Names() {
+			if named, ok := g.localStruct(scope.Lookup(name).Type()); ok && g.sensitive(named, map[types.Type]bool{}) {
+				queue = append(queue, named)
+			}
+		}
+	}
+
+	for _, name := range g.cfg.typeNames {
+		obj := g.pkg.Scope().Lookup(name)
+		if obj == nil {
+			return fmt.Errorf("type %s not found in package %s", name, g.pkg.Name())
+		}
+
+		named, ok := g.localStruct(obj.Type())
+		if !ok {
+			return fmt.Errorf("type %s is not a struct", name)
+		}
+
+		if !g.sensitive(named, map[types.Type]bool{}) {
+			return fmt.Errorf("type %s has no sensitive fields", name)
+		}
+
+		queue = append(queue, named)
+	}
+
+	// Structs of the package reachable from the targets get methods too,
+	// so the targets can call them.
+	for len(queue) > 0 {
+		named := queue[0]
+		queue = queue[1:]
+
+		if g.targets[named] {
+			continue
+		}
+
+		if obj, _, _ := types.LookupFieldOrMethod(named, false, g.pkg, "Redact"); obj != nil {
+			return fmt.Errorf("type %s already has a Redact field or method", named.Obj().Name())
+		}
+
+		g.targets[named] = true
+
+		st := named.Underlying().(*types.Struct)
+		for i := 0; i < st.NumFields(); i++ {
+			queue = append(queue, g.reachableStructs(st.Field(i).Type(), map[types.Type]bool{})...)
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) reachableStructs(typ types.Type, seen map[types.Type]bool) []*types.Named {
+	if seen[typ] || !g.sensitive(typ, map[types.Type]bool{}) {
+		return nil
+	}
+	seen[typ] = true
+
+	if named, ok := g.localStruct(typ); ok {
+		return []*types.Named{named}
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Pointer:
+		return g.reachableStructs(t.Elem(), seen)
+	case *types.Slice:
+		return g.reachableStructs(t.Elem(), seen)
+	case *types.Array:
+		return g.reachableStructs(t.Elem(), seen)
+	case *types.Map:
+		return g.reachableStructs(t.Elem(), seen)
+	}
+
+	return nil
+}
+
+// localStruct tells whether typ is a non-generic struct type declared in the
+// generated package.
+func (g *generator) localStruct(typ types.Type) (*types.Named, bool) {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() != g.pkg || named.TypeParams().Len() > 0 {
+		return nil, false
+	}
+
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil, false
+	}
+
+	return named, true
+}
+
+// sensitive mirrors the analysis desensitivize does at runtime: interfaces may
+// hold anything, unexported fields count if they lead to tags or are
+// interfaces and maps with string keys count since key rules may be set.
+func (g *generator) sensitive(typ types.Type, seen map[types.Type]bool) bool {
+	return g.reaches(typ, true, seen)
+}
+
+// reachesTag tells whether typ leads to tags without going through
+// interfaces. Such unexported fields are always zeroed, unexported interfaces
+// only if they hold sensitive data.
+func (g *generator) reachesTag(typ types.Type, seen map[types.Type]bool) bool {
+	return g.reaches(typ, false, seen)
+}
+
+func (g *generator) reaches(typ types.Type, dynamic bool, seen map[types.Type]bool) bool {
+	if builtin(typ) {
+		return true
+	}
+
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	switch t := typ.Underlying().(type) {
+	case *types.Interface:
+		return dynamic
+	case *types.Pointer:
+		return g.reaches(t.Elem(), dynamic, seen)
+	case *types.Slice:
+		return g.reaches(t.Elem(), dynamic, seen)
+	case *types.Array:
+		return g.reaches(t.Elem(), dynamic, seen)
+	case *types.Map:
+		if dynamic && stringKeyed(t) {
+			return true
+		}
+
+		switch t.Key().Underlying().(type) {
+		case *types.Struct, *types.Array, *types.Pointer:
+			if g.reaches(t.Key(), dynamic, seen) {
+				return true
+			}
+		}
+		return g.reaches(t.Elem(), dynamic, seen)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if _, tagged := g.lookupTag(t, i); tagged {
+				return true
+			}
+
+			field := t.Field(i)
+			if !field.Exported() && dynamic {
+				if isInterface(field.Type()) || g.reachesTag(field.Type(), map[types.Type]bool{}) {
+					return true
+				}
+				continue
+			}
+
+			if g.reaches(field.Type(), dynamic, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// leadsTo tells whether the generated code may reach a value of target from
+// typ. Unlike at runtime interfaces don't count, since the values they hold
+// are redacted with reflection.
+func (g *generator) leadsTo(typ, target types.Type, seen map[types.Type]bool) bool {
+	if types.Identical(typ, target) {
+		return true
+	}
+
+	if seen[typ] || builtin(typ) {
+		return false
+	}
+	seen[typ] = true
+
+	switch t := typ.Underlying().(type) {
+	case *types.Pointer:
+		return g.leadsTo(t.Elem(), target, seen)
+	case *types.Slice:
+		return g.leadsTo(t.Elem(), target, seen)
+	case *types.Array:
+		return g.leadsTo(t.Elem(), target, seen)
+	case *types.Map:
+		return g.leadsTo(t.Key(), target, seen) || g.leadsTo(t.Elem(), target, seen)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if t.Field(i).Exported() && g.leadsTo(t.Field(i).Type(), target, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isInterface(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Interface)
+	return ok
+}
+
+func stringKeyed(m *types.Map) bool {
+	key, ok := m.Key().Underlying().(*types.Basic)
+	return ok && key.Info()&types.IsString != 0
+}
+
+// builtinTypes have their own handling in desensitivize, so they are
+// redacted with reflection.
+var builtinTypes = map[string]bool{
+	"net/url.URL":         true,
+	"net/url.Values":      true,
+	"net/http.Header":     true,
+	"net/http.Cookie":     true,
+	"net/http.Request":    true,
+	"net/http.Response":   true,
+	"mime/multipart.Form": true,
+}
+
+func builtin(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+
+	return builtinTypes[named.Obj().Pkg().Path()+"."+named.Obj().Name()]
+}
+
+func (g *generator) lookupTag(st *types.Struct, index int) (string, bool) {
+	tag := reflect.StructTag(st.Tag(index))
+	for _, name := range g.cfg.tagNames {
+		if value, exist := tag.Lookup(name); exist {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func (g *generator) writeRedact(w *bytes.Buffer, named *types.Named) {
+	name := named.Obj().Name()
+	st := named.Underlying().(*types.Struct)
+
+	// Imports are only kept when the statically generated body is used.
+	imports := g.imports
+	g.imports = map[string]string{}
+	g.vars = 0
+
+	var (
+		body     bytes.Buffer
+		fallback string
+	)
+
+	for i := 0; i < st.NumFields() && fallback == ""; i++ {
+		fallback = g.writeField(&body, st, i)
+	}
+
+	staticImports := g.imports
+	g.imports = imports
+
+	fmt.Fprintf(w, "// Redact returns a copy of s with its sensitive fields redacted.\n")
+	fmt.Fprintf(w, "func (s %s) Redact() %s {\n", name, name)
+
+	if fallback != "" {
+		fmt.Fprintf(w, "// %s, so it's redacted with reflection.\n", fallback)
+		fmt.Fprintf(w, "return %s.Redact(s)\n}\n\n", g.lib())
+		return
+	}
+
+	for path, pkgName := range staticImports {
+		g.imports[path] = pkgName
+	}
+
+	fmt.Fprintf(w, "redacted := s\n%sreturn redacted\n}\n\n", body.String())
+}
+
+// writeField writes the statements redacting a field into redacted. It
+// returns why the struct can't be redacted statically, if it can't.
+func (g *generator) writeField(w *bytes.Buffer, st *types.Struct, index int) string {
+	field := st.Field(index)
+	src := "s." + field.Name()
+	dst := "redacted." + field.Name()
+
+	tag, tagged := g.lookupTag(st, index)
+	if !tagged {
+		switch {
+		case !field.Exported():
+			switch {
+			case g.reachesTag(field.Type(), map[types.Type]bool{}):
+				fmt.Fprintf(w, "%s = %s\n", dst, g.zero(field.Type()))
+			case isInterface(field.Type()):
+				fmt.Fprintf(w, "if %s.NeedsRedaction(%s) {\n%s = nil\n}\n", g.lib(), src, dst)
+			}
+		case !g.sensitive(field.Type(), map[types.Type]bool{}):
+		default:
+			g.walk(w, dst, src, field.Type())
+		}
+		return ""
+	}
+
+	if !field.Exported() {
+		fmt.Fprintf(w, "%s = %s\n", dst, g.zero(field.Type()))
+		return ""
+	}
+
+	spec := parseTag(tag)
+	if spec.encoding != "" {
+		return fmt.Sprintf("%s holds a %s document", field.Name(), spec.encoding)
+	}
+
+	if spec.scope == scopeKeys {
+		if _, isMap := field.Type().Underlying().(*types.Map); isMap {
+			return fmt.Sprintf("%s redacts map keys", field.Name())
+		}
+	}
+
+	if spec.condition == "" {
+		g.redactField(w, dst, src, field.Type(), spec)
+		return ""
+	}
+
+	cond, err := g.condition(st, spec.condition)
+	if err != nil {
+		return fmt.Sprintf("%s has the condition %q which %v", field.Name(), spec.condition, err)
+	}
+
+	fmt.Fprintf(w, "if %s {\n", cond)
+	g.redactField(w, dst, src, field.Type(), spec)
+	if g.sensitive(field.Type(), map[types.Type]bool{}) {
+		w.WriteString("} else {\n")
+		g.walk(w, dst, src, field.Type())
+	}
+	w.WriteString("}\n")
+
+	return ""
+}
+
+func (g *generator) redactField(w *bytes.Buffer, dst, src string, typ types.Type, spec tagSpec) {
+	if spec.scope == scopeEach {
+		g.each(w, dst, src, typ, spec.strategy)
+		return
+	}
+
+	mapType, isMap := typ.Underlying().(*types.Map)
+	if !isMap || spec.scope == scopeWhole {
+		g.redactValue(w, dst, src, typ, spec.strategy)
+		return
+	}
+
+	basic, stringKeys := mapType.Key().Underlying().(*types.Basic)
+	stringKeys = stringKeys && basic.Info()&types.IsString != 0
+	if spec.scope == scopeEntries && !stringKeys {
+		g.redactValue(w, dst, src, typ, spec.strategy)
+		return
+	}
+
+	redacted, key, value := g.tmp(), g.tmp(), g.tmp()
+	fmt.Fprintf(w, "if %s != nil {\n", src)
+	fmt.Fprintf(w, "%s := make(%s, len(%s))\n", redacted, g.typeString(typ), src)
+	fmt.Fprintf(w, "for %s, %s := range %s {\n", key, value, src)
+
+	if spec.scope == scopeValues {
+		g.redactValue(w, value, value, mapType.Elem(), spec.strategy)
+	} else {
+		matches := make([]string, len(spec.entries))
+		for i, entry := range spec.entries {
+			matches[i] = fmt.Sprintf("%s.EqualFold(%q, string(%s))", g.use("strings"), entry, key)
+		}
+
+		fmt.Fprintf(w, "if %s {\n", strings.Join(matches, " || "))
+		g.redactValue(w, value, value, mapType.Elem(), spec.strategy)
+		if g.sensitive(mapType.Elem(), map[types.Type]bool{}) {
+			w.WriteString("} else {\n")
+			g.walk(w, value, value, mapType.Elem())
+		}
+		w.WriteString("}\n")
+	}
+
+	fmt.Fprintf(w, "%s[%s] = %s\n}\n%s = %s\n}\n", redacted, key, value, dst, redacted)
+}
+
+func (g *generator) redactValue(w *bytes.Buffer, dst, src string, typ types.Type, strategy string) {
+	fmt.Fprintf(w, "%s = %s.RedactField(%s, %q)\n", dst, g.lib(), src, strategy)
+}
+
+// each mirrors the each option, redacting every element of nested slices,
+// arrays and pointers.
+func (g *generator) each(w *bytes.Buffer, dst, src string, typ types.Type, strategy string) {
+	switch t := typ.Underlying().(type) {
+	case *types.Slice:
+		redacted, index := g.tmp(), g.tmp()
+		fmt.Fprintf(w, "if %s != nil {\n", src)
+		fmt.Fprintf(w, "%s := make(%s, len(%s))\n", redacted, g.typeString(typ), src)
+		fmt.Fprintf(w, "for %s := range %s {\n", index, src)
+		g.each(w, redacted+"["+index+"]", src+"["+index+"]", t.Elem(), strategy)
+		fmt.Fprintf(w, "}\n%s = %s\n}\n", dst, redacted)
+	case *types.Array:
+		redacted, index := g.tmp(), g.tmp()
+		fmt.Fprintf(w, "var %s %s\n", redacted, g.typeString(typ))
+		fmt.Fprintf(w, "for %s := range %s {\n", index, src)
+		g.each(w, redacted+"["+index+"]", src+"["+index+"]", t.Elem(), strategy)
+		fmt.Fprintf(w, "}\n%s = %s\n", dst, redacted)
+	case *types.Pointer:
+		redacted := g.tmp()
+		fmt.Fprintf(w, "if %s != nil {\n", src)
+		fmt.Fprintf(w, "var %s %s\n", redacted, g.typeString(t.Elem()))
+		g.each(w, redacted, "(*"+src+")", t.Elem(), strategy)
+		fmt.Fprintf(w, "%s = &%s\n}\n", dst, redacted)
+	default:
+		g.redactValue(w, dst, src, typ, strategy)
+	}
+}
+
+// walk writes the statements assigning a redacted copy of src to dst. It is
+// only called for sensitive types.
+func (g *generator) walk(w *bytes.Buffer, dst, src string, typ types.Type) {
+	if named, ok := g.localStruct(typ); ok && g.targets[named] {
+		fmt.Fprintf(w, "%s = %s.Redact()\n", dst, src)
+		return
+	}
+
+	if builtin(typ) {
+		fmt.Fprintf(w, "%s = %s.Redact(%s)\n", dst, g.lib(), src)
+		return
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Pointer:
+		if g.leadsTo(t.Elem(), t, map[types.Type]bool{}) {
+			// The value may point back to itself, which only Redact follows.
+			fmt.Fprintf(w, "%s = %s.Redact(%s)\n", dst, g.lib(), src)
+			return
+		}
+
+		redacted := g.tmp()
+		fmt.Fprintf(w, "if %s != nil {\n%s := *%s\n", src, redacted, src)
+		g.walk(w, redacted, redacted, t.Elem())
+		fmt.Fprintf(w, "%s = &%s\n}\n", dst, redacted)
+	case *types.Slice:
+		redacted, index := g.tmp(), g.tmp()
+		fmt.Fprintf(w, "if %s != nil {\n", src)
+		fmt.Fprintf(w, "%s := make(%s, len(%s))\n", redacted, g.typeString(typ), src)
+		fmt.Fprintf(w, "for %s := range %s {\n", index, src)
+		g.walk(w, redacted+"["+index+"]", src+"["+index+"]", t.Elem())
+		fmt.Fprintf(w, "}\n%s = %s\n}\n", dst, redacted)
+	case *types.Array:
+		redacted, index := g.tmp(), g.tmp()
+		fmt.Fprintf(w, "%s := %s\n", redacted, src)
+		fmt.Fprintf(w, "for %s := range %s {\n", index, redacted)
+		g.walk(w, redacted+"["+index+"]", redacted+"["+index+"]", t.Elem())
+		fmt.Fprintf(w, "}\n%s = %s\n", dst, redacted)
+	case *types.Map:
+		if g.sensitive(t.Key(), map[types.Type]bool{}) {
+			// Redacted keys may collide, which the collision policy decides.
+			fmt.Fprintf(w, "%s = %s.Redact(%s)\n", dst, g.lib(), src)
+			return
+		}
+
+		if stringKeyed(t) {
+			// Key rules are set at runtime.
+			fmt.Fprintf(w, "if %s.HasKeyRules() {\n%s = %s.Redact(%s)\n} else ", g.lib(), dst, g.lib(), src)
+		}
+
+		redacted, key, value := g.tmp(), g.tmp(), g.tmp()
+		fmt.Fprintf(w, "if %s != nil {\n", src)
+		fmt.Fprintf(w, "%s := make(%s, len(%s))\n", redacted, g.typeString(typ), src)
+		fmt.Fprintf(w, "for %s, %s := range %s {\n", key, value, src)
+		g.walk(w, value, value, t.Elem())
+		fmt.Fprintf(w, "%s[%s] = %s\n}\n%s = %s\n}\n", redacted, key, value, dst, redacted)
+	default:
+		fmt.Fprintf(w, "%s = %s.Redact(%s)\n", dst, g.lib(), src)
+	}
+}
+
+// condition translates an if= condition to a Go expression over the fields
+// of s, comparing them the way desensitivize does at runtime.
+func (g *generator) condition(st *types.Struct, expr string) (string, error) {
+	cond, err := parseCondition(expr)
+	if err != nil {
+		return "", err
+	}
+
+	var field *types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Name() == cond.field {
+			field = st.Field(i)
+		}
+	}
+
+	if field == nil {
+		return "", fmt.Errorf("refers to an unknown field")
+	}
+
+	basic, ok := field.Type().Underlying().(*types.Basic)
+	if !ok {
+		return "", fmt.Errorf("compares a %s", field.Type())
+	}
+
+	// fmt.Sprint prints the field like desensitivize does, using its String
+	// method if it has one.
+	value := fmt.Sprintf("%s.Sprint(s.%s)", g.use("fmt"), field.Name())
+	if basic.Info()&types.IsString != 0 && types.NewMethodSet(field.Type()).Len() == 0 {
+		value = fmt.Sprintf("string(s.%s)", field.Name())
+	}
+
+	comparisons := make([]string, len(cond.values))
+	for i, expected := range cond.values {
+		comparisons[i] = fmt.Sprintf("%s == %s", value, strconv.Quote(expected))
+	}
+
+	matched := strings.Join(comparisons, " || ")
+	switch cond.op {
+	case condNotEqual, condNotIn:
+		return "!(" + matched + ")", nil
+	case condIn:
+		return "(" + matched + ")", nil
+	}
+
+	return matched, nil
+}
+
+func (g *generator) zero(typ types.Type) string {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsString != 0:
+			return `""`
+		case t.Info()&types.IsBoolean != 0:
+			return "false"
+		}
+		return "0"
+	case *types.Struct, *types.Array:
+		return g.typeString(typ) + "{}"
+	}
+
+	return "nil"
+}
+
+func (g *generator) tmp() string {
+	g.vars++
+	return "v" + strconv.Itoa(g.vars)
+}
+
+func (g *generator) lib() string {
+	return g.use(libPath)
+}
+
+func (g *generator) use(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+	g.imports[path] = name
+	return name
+}
+
+func (g *generator) typeString(typ types.Type) string {
+	return types.TypeString(typ, func(pkg *types.Package) string {
+		if pkg == g.pkg {
+			return ""
+		}
+
+		g.imports[pkg.Path()] = pkg.Name()
+		return pkg.Name()
+	})
+}
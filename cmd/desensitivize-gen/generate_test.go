@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateIsUpToDate(t *testing.T) {
+	dir := filepath.Join("internal", "conformance")
+
+	src, err := generate(config{
+		dir:      dir,
+		output:   defaultOutput,
+		tagNames: []string{"sensitive"},
+	})
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile(filepath.Join(dir, defaultOutput))
+	require.NoError(t, err)
+	require.Equal(t, string(committed), string(src), "run go generate ./...")
+}
+
+func TestGenerateTypeErrors(t *testing.T) {
+	dir := filepath.Join("internal", "conformance")
+
+	for typeName, expected := range map[string]string{
+		"Missing": "type Missing not found in package conformance",
+		"Role":    "type Role is not a struct",
+		"Plain":   "type Plain has no sensitive fields",
+	} {
+		_, err := generate(config{
+			dir:       dir,
+			output:    defaultOutput,
+			typeNames: []string{typeName},
+			tagNames:  []string{"sensitive"},
+		})
+		require.EqualError(t, err, expected)
+	}
+}
+
+func TestGenerateBeforeMethodsExist(t *testing.T) {
+	src, err := generate(config{
+		dir:      filepath.Join("testdata", "pending"),
+		output:   defaultOutput,
+		tagNames: []string{"sensitive"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, string(src), "func (s User) Redact() User {")
+
+	_, err = generate(config{
+		dir:      filepath.Join("testdata", "broken"),
+		output:   defaultOutput,
+		tagNames: []string{"sensitive"},
+	})
+	require.ErrorContains(t, err, "broken.go:12:17")
+}
+
+func TestGenerateSelectedTypes(t *testing.T) {
+	src, err := generate(config{
+		dir:       filepath.Join("internal", "conformance"),
+		output:    defaultOutput,
+		typeNames: []string{"Node"},
+		tagNames:  []string{"sensitive"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, string(src), "func (s Node) Redact() Node {")
+	require.NotContains(t, string(src), "func (s User) Redact() User {")
+}
@@ -0,0 +1,211 @@
+// Code generated by desensitivize-gen. DO NOT EDIT.
+
+package conformance
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xxbtwxx/desensitivize"
+)
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Address) Redact() Address {
+	redacted := s
+	redacted.Street = desensitivize.RedactField(s.Street, "-")
+	return redacted
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Base) Redact() Base {
+	redacted := s
+	redacted.Secret = desensitivize.RedactField(s.Secret, "-")
+	return redacted
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Card) Redact() Card {
+	redacted := s
+	redacted.Number = desensitivize.RedactField(s.Number, "mask:last=4")
+	redacted.Holder = desensitivize.RedactField(s.Holder, "placeholder")
+	return redacted
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Chain) Redact() Chain {
+	redacted := s
+	redacted.Name = desensitivize.RedactField(s.Name, "-")
+	redacted.Next = desensitivize.Redact(s.Next)
+	return redacted
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Endpoint) Redact() Endpoint {
+	redacted := s
+	redacted.Name = desensitivize.RedactField(s.Name, "mask")
+	if s.URL != nil {
+		v1 := *s.URL
+		v1 = desensitivize.Redact(v1)
+		redacted.URL = &v1
+	}
+	redacted.Base = desensitivize.Redact(s.Base)
+	redacted.Query = desensitivize.Redact(s.Query)
+	redacted.Header = desensitivize.Redact(s.Header)
+	return redacted
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Envelope) Redact() Envelope {
+	// Body holds a json document, so it's redacted with reflection.
+	return desensitivize.Redact(s)
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Key) Redact() Key {
+	redacted := s
+	redacted.ID = desensitivize.RedactField(s.ID, "-")
+	return redacted
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Keyed) Redact() Keyed {
+	// Tags redacts map keys, so it's redacted with reflection.
+	return desensitivize.Redact(s)
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Node) Redact() Node {
+	redacted := s
+	redacted.Name = desensitivize.RedactField(s.Name, "upper|mask:first=2")
+	if s.Children != nil {
+		v1 := make([]*Node, len(s.Children))
+		for v2 := range s.Children {
+			v1[v2] = desensitivize.Redact(s.Children[v2])
+		}
+		redacted.Children = v1
+	}
+	return redacted
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s Predicated) Redact() Predicated {
+	// Name has the condition "isAdmin" which is a predicate, so it's redacted with reflection.
+	return desensitivize.Redact(s)
+}
+
+// Redact returns a copy of s with its sensitive fields redacted.
+func (s User) Redact() User {
+	redacted := s
+	redacted.Base = s.Base.Redact()
+	redacted.Email = desensitivize.RedactField(s.Email, "-")
+	redacted.Phone = desensitivize.RedactField(s.Phone, "-")
+	if string(s.Role) == "admin" {
+		redacted.Note = desensitivize.RedactField(s.Note, "-")
+	}
+	if fmt.Sprint(s.Level) == "1" || fmt.Sprint(s.Level) == "2" {
+		redacted.Bio = desensitivize.RedactField(s.Bio, "-")
+	}
+	if !(string(s.Role) == "guest") {
+		redacted.Alias = desensitivize.RedactField(s.Alias, "-")
+	}
+	if fmt.Sprint(s.Priority) == "high" {
+		redacted.Escalated = desensitivize.RedactField(s.Escalated, "-")
+	}
+	if string(s.Role) == "guest" {
+		redacted.Address = desensitivize.RedactField(s.Address, "")
+	} else {
+		redacted.Address = s.Address.Redact()
+	}
+	if s.Home != nil {
+		v1 := *s.Home
+		v1 = v1.Redact()
+		redacted.Home = &v1
+	}
+	if s.Addresses != nil {
+		v2 := make([]Address, len(s.Addresses))
+		for v3 := range s.Addresses {
+			v2[v3] = s.Addresses[v3].Redact()
+		}
+		redacted.Addresses = v2
+	}
+	v4 := s.Previous
+	for v5 := range v4 {
+		v4[v5] = v4[v5].Redact()
+	}
+	redacted.Previous = v4
+	if desensitivize.HasKeyRules() {
+		redacted.ByName = desensitivize.Redact(s.ByName)
+	} else if s.ByName != nil {
+		v6 := make(map[string]*Address, len(s.ByName))
+		for v7, v8 := range s.ByName {
+			if v8 != nil {
+				v9 := *v8
+				v9 = v9.Redact()
+				v8 = &v9
+			}
+			v6[v7] = v8
+		}
+		redacted.ByName = v6
+	}
+	if s.Cards != nil {
+		v10 := make([]Card, len(s.Cards))
+		for v11 := range s.Cards {
+			v10[v11] = s.Cards[v11].Redact()
+		}
+		redacted.Cards = v10
+	}
+	if s.Tokens != nil {
+		v12 := make([]string, len(s.Tokens))
+		for v13 := range s.Tokens {
+			v12[v13] = desensitivize.RedactField(s.Tokens[v13], "mask:first=2")
+		}
+		redacted.Tokens = v12
+	}
+	var v14 [2]*string
+	for v15 := range s.Codes {
+		if s.Codes[v15] != nil {
+			var v16 string
+			v16 = desensitivize.RedactField((*s.Codes[v15]), "-")
+			v14[v15] = &v16
+		}
+	}
+	redacted.Codes = v14
+	if s.Headers != nil {
+		v17 := make(map[string]string, len(s.Headers))
+		for v18, v19 := range s.Headers {
+			if strings.EqualFold("authorization", string(v18)) || strings.EqualFold("cookie", string(v18)) {
+				v19 = desensitivize.RedactField(v19, "-")
+			}
+			v17[v18] = v19
+		}
+		redacted.Headers = v17
+	}
+	if s.Secrets != nil {
+		v20 := make(map[string]string, len(s.Secrets))
+		for v21, v22 := range s.Secrets {
+			v22 = desensitivize.RedactField(v22, "-")
+			v20[v21] = v22
+		}
+		redacted.Secrets = v20
+	}
+	if s.Mixed != nil {
+		v23 := make(map[string]Address, len(s.Mixed))
+		for v24, v25 := range s.Mixed {
+			if strings.EqualFold("work", string(v24)) {
+				v25 = desensitivize.RedactField(v25, "")
+			} else {
+				v25 = v25.Redact()
+			}
+			v23[v24] = v25
+		}
+		redacted.Mixed = v23
+	}
+	redacted.Tree = desensitivize.Redact(s.Tree)
+	redacted.Extra = desensitivize.Redact(s.Extra)
+	redacted.password = ""
+	redacted.home = nil
+	if desensitivize.NeedsRedaction(s.err) {
+		redacted.err = nil
+	}
+	return redacted
+}
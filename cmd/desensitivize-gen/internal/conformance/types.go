@@ -0,0 +1,123 @@
+// Package conformance holds types covering the tag options supported by
+// desensitivize-gen. Its tests compare the generated Redact methods with the
+// reflective desensitivize.Redact.
+package conformance
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//go:generate go run ../..
+
+type Role string
+
+type Level int
+
+// Priority is compared by its String method in conditions.
+type Priority int
+
+func (p Priority) String() string {
+	if p > 1 {
+		return "high"
+	}
+
+	return "low"
+}
+
+type Address struct {
+	Street string `sensitive:"-"`
+	City   string
+}
+
+type Card struct {
+	Number string `sensitive:"mask:last=4"`
+	Holder string `sensitive:"placeholder"`
+}
+
+type Base struct {
+	Secret string `sensitive:"-"`
+}
+
+type Node struct {
+	Name     string `sensitive:"upper|mask:first=2"`
+	Children []*Node
+}
+
+// Chain may point back to itself, so its links are redacted with reflection.
+type Chain struct {
+	Name string `sensitive:"-"`
+	Next *Chain
+}
+
+type Key struct {
+	ID   string `sensitive:"-"`
+	Name string
+}
+
+type User struct {
+	Base
+	ID        int
+	Name      string
+	Email     string  `sensitive:"-"`
+	Phone     *string `sensitive:"-"`
+	Role      Role
+	Level     Level
+	Priority  Priority
+	Note      string  `sensitive:"-,if=Role==admin"`
+	Bio       string  `sensitive:"-,if=Level in 1,2"`
+	Alias     string  `sensitive:"-,if=Role != guest"`
+	Escalated string  `sensitive:"-,if=Priority==high"`
+	Address   Address `sensitive:"if=Role==guest"`
+	Home      *Address
+	Addresses []Address
+	Previous  [2]Address
+	ByName    map[string]*Address
+	Cards     []Card
+	Tokens    []string           `sensitive:"each,mask:first=2"`
+	Codes     [2]*string         `sensitive:"each,-"`
+	Headers   map[string]string  `sensitive:"entries=authorization|cookie,-"`
+	Secrets   map[string]string  `sensitive:"values,-"`
+	Mixed     map[string]Address `sensitive:"entries=work"`
+	Tree      *Node
+	Manager   *Plain
+	Created   time.Time
+	Extra     any
+	password  string `sensitive:"-"`
+	home      *Address
+	count     int
+	err       error
+}
+
+// Endpoint holds types desensitivize handles on its own.
+type Endpoint struct {
+	Name   string `sensitive:"mask"`
+	URL    *url.URL
+	Base   url.URL
+	Query  url.Values
+	Header http.Header
+}
+
+// Keyed falls back to reflection for its keys since redacted keys may
+// collide.
+type Keyed struct {
+	Keys map[Key]int
+	Tags map[string]int `sensitive:"keys,-"`
+}
+
+// Predicated falls back to reflection since predicates are registered at
+// runtime.
+type Predicated struct {
+	Name string `sensitive:"-,if=isAdmin"`
+}
+
+// Envelope falls back to reflection since its body is a JSON document.
+type Envelope struct {
+	Body []byte `sensitive:"json:$.password"`
+}
+
+// Plain has no sensitive fields and gets no method.
+type Plain struct {
+	Name string
+}
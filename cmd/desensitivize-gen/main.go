@@ -0,0 +1,72 @@
+// Command desensitivize-gen generates Redact methods for the structs of a
+// package which contain fields tagged as sensitive. The generated methods
+// copy and redact values without reflection, producing the same result as
+// desensitivize.Redact in CopyOnWrite mode: whatever isn't redacted, such as
+// untagged slices, maps and pointers, is shared with the original regardless
+// of desensitivize.SetCopyMode, so mutating it through the result mutates the
+// original too. Values which have to be deep copied should go through
+// desensitivize.Redact in CopyDeep mode instead.
+//
+// Typical usage is a go:generate directive next to the types:
+//
+//	//go:generate go run github.com/xxbtwxx/desensitivize/cmd/desensitivize-gen -type User,Order
+//
+// The package has to type-check, apart from uses of the Redact methods about
+// to be generated. Its dependencies are loaded from the export data built by
+// go list.
+//
+// Policies and tag value mappings are configured at runtime, so the generator
+// doesn't see them. Fields it can't redact statically, such as interfaces or
+// pointers to types which may point back to themselves, fall back to
+// desensitivize.Redact.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultOutput = "redact_gen.go"
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("desensitivize-gen: ")
+
+	var (
+		typeNames = flag.String("type", "", "comma-separated list of type names; defaults to every struct with sensitive fields")
+		output    = flag.String("output", "", "output file name; defaults to "+defaultOutput+" in the package directory")
+		tagNames  = flag.String("tags", "sensitive", "comma-separated list of struct tag names marking sensitive fields")
+	)
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = filepath.Join(dir, defaultOutput)
+	}
+
+	cfg := config{
+		dir:      dir,
+		output:   filepath.Base(outputPath),
+		tagNames: strings.Split(*tagNames, ","),
+	}
+	if *typeNames != "" {
+		cfg.typeNames = strings.Split(*typeNames, ",")
+	}
+
+	src, err := generate(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(outputPath, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
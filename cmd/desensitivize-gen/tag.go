@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The tag grammar mirrors the one of the desensitivize package. The
+// conformance tests catch the two drifting apart.
+
+type tagScope int
+
+const (
+	scopeWhole tagScope = iota
+	scopeKeys
+	scopeValues
+	scopeEntries
+	scopeEach
+)
+
+type tagSpec struct {
+	strategy  string
+	scope     tagScope
+	entries   []string
+	condition string
+	encoding  string
+}
+
+func parseTag(tag string) tagSpec {
+	var (
+		spec     tagSpec
+		strategy []string
+	)
+
+	options := strings.Split(tag, ",")
+	for i, option := range options {
+		if strings.HasPrefix(option, "if=") {
+			spec.condition = strings.TrimPrefix(strings.Join(options[i:], ","), "if=")
+			break
+		}
+
+		if spec.encoding != "" {
+			continue
+		}
+
+		switch {
+		case option == "keys":
+			spec.scope = scopeKeys
+		case option == "values":
+			spec.scope = scopeValues
+		case option == "each":
+			spec.scope = scopeEach
+		case strings.HasPrefix(option, "entries="):
+			spec.scope = scopeEntries
+			spec.entries = strings.Split(strings.TrimPrefix(option, "entries="), "|")
+		case strings.HasPrefix(option, "json:"), strings.HasPrefix(option, "base64json:"):
+			spec.encoding, _, _ = strings.Cut(option, ":")
+		default:
+			strategy = append(strategy, option)
+		}
+	}
+
+	spec.strategy = strings.Join(strategy, ",")
+	return spec
+}
+
+type condOp int
+
+const (
+	condPredicate condOp = iota
+	condEqual
+	condNotEqual
+	condIn
+	condNotIn
+)
+
+type condition struct {
+	op     condOp
+	field  string
+	values []string
+}
+
+// parseCondition parses comparisons only. Predicates are registered at
+// runtime, so they can't be generated.
+func parseCondition(expr string) (condition, error) {
+	expr = strings.TrimSpace(expr)
+
+	for _, op := range []struct {
+		token string
+		op    condOp
+	}{
+		{" not in ", condNotIn},
+		{" in ", condIn},
+		{"!=", condNotEqual},
+		{"==", condEqual},
+	} {
+		field, values, found := strings.Cut(expr, op.token)
+		if !found {
+			continue
+		}
+
+		cond := condition{
+			op:    op.op,
+			field: strings.TrimSpace(field),
+		}
+
+		if op.op == condIn || op.op == condNotIn {
+			for _, value := range strings.Split(values, ",") {
+				cond.values = append(cond.values, unquote(strings.TrimSpace(value)))
+			}
+		} else {
+			cond.values = []string{unquote(strings.TrimSpace(values))}
+		}
+
+		if cond.field == "" {
+			return condition{}, fmt.Errorf("has no field")
+		}
+
+		return cond, nil
+	}
+
+	return condition{}, fmt.Errorf("is a predicate")
+}
+
+func unquote(value string) string {
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+
+	return value
+}
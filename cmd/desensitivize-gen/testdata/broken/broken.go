@@ -0,0 +1,12 @@
+// Package broken has a type error besides the missing Redact method.
+package broken
+
+type User struct {
+	Email string `sensitive:"-"`
+}
+
+func redacted(u User) User {
+	return u.Redact()
+}
+
+var count int = "many"
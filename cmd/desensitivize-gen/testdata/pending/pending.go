@@ -0,0 +1,16 @@
+// Package pending uses the Redact method before it's generated.
+package pending
+
+type User struct {
+	Email string `sensitive:"-"`
+}
+
+type Redacter interface {
+	Redact() User
+}
+
+var _ Redacter = User{}
+
+func redacted(u User) User {
+	return u.Redact()
+}
@@ -0,0 +1,118 @@
+package desensitivize
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// CollisionPolicy decides what happens when several map keys redact to the
+// same value.
+type CollisionPolicy int
+
+const (
+	// CollisionOverwrite keeps the entry whose original key sorts last.
+	CollisionOverwrite CollisionPolicy = iota
+	// CollisionError keeps the first entry and makes TryRedact fail with ErrKeyCollision.
+	CollisionError
+	// CollisionKeepFirst keeps the entry whose original key sorts first.
+	CollisionKeepFirst
+	// CollisionMerge keeps the first entry and lists the values of all
+	// colliding entries in Stats.Merged.
+	CollisionMerge
+	// CollisionSuffix appends "#1", "#2", ... to colliding string keys in
+	// the sorted order of their original keys. Other keys are handled like
+	// CollisionMerge.
+	CollisionSuffix
+)
+
+var ErrKeyCollision = errors.New("desensitivize: redacted map keys collide")
+
+var collisionPolicy CollisionPolicy
+
+func SetCollisionPolicy(policy CollisionPolicy) {
+	collisionPolicy = policy
+}
+
+// Collision lists the values of all entries sharing a redacted key.
+type Collision struct {
+	Key    any
+	Values []any
+}
+
+type mapEntry struct {
+	origKey reflect.Value
+	key     reflect.Value
+	elem    reflect.Value
+}
+
+func (r *redactor) buildRedactedMap(mapType reflect.Type, entries []mapEntry) reflect.Value {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return sortKey(entries[i].origKey) < sortKey(entries[j].origKey)
+	})
+
+	redactedMap := reflect.MakeMapWithSize(mapType, len(entries))
+	merged := map[any]int{}
+	suffixes := map[string]int{}
+
+	for _, entry := range entries {
+		existing := redactedMap.MapIndex(entry.key)
+		if !existing.IsValid() {
+			redactedMap.SetMapIndex(entry.key, entry.elem)
+			continue
+		}
+
+		r.stats.Collisions++
+
+		switch collisionPolicy {
+		case CollisionOverwrite:
+			redactedMap.SetMapIndex(entry.key, entry.elem)
+		case CollisionError:
+			r.fail(fmt.Errorf("%w: %v", ErrKeyCollision, entry.key))
+		case CollisionMerge:
+			r.merge(merged, entry, existing)
+		case CollisionSuffix:
+			if entry.key.Kind() != reflect.String {
+				r.merge(merged, entry, existing)
+				continue
+			}
+
+			for {
+				suffixes[entry.key.String()]++
+				key := reflect.New(entry.key.Type()).Elem()
+				key.SetString(fmt.Sprintf("%s#%d", entry.key.String(), suffixes[entry.key.String()]))
+
+				if !redactedMap.MapIndex(key).IsValid() {
+					redactedMap.SetMapIndex(key, entry.elem)
+					break
+				}
+			}
+		}
+	}
+
+	return redactedMap
+}
+
+func (r *redactor) merge(merged map[any]int, entry mapEntry, existing reflect.Value) {
+	index, exist := merged[entry.key.Interface()]
+	if !exist {
+		index = len(r.stats.Merged)
+		merged[entry.key.Interface()] = index
+		r.stats.Merged = append(r.stats.Merged, Collision{
+			Key:    entry.key.Interface(),
+			Values: []any{existing.Interface()},
+		})
+	}
+	r.stats.Merged[index].Values = append(r.stats.Merged[index].Values, entry.elem.Interface())
+}
+
+// sortKey orders keys by their printed value and dynamic type. Keys printing
+// the same, such as pointers to equal values or NaN, keep map iteration order.
+func sortKey(key reflect.Value) string {
+	for key.Kind() == reflect.Pointer && !key.IsNil() {
+		key = key.Elem()
+	}
+
+	return fmt.Sprintf("%v\x00%T", key.Interface(), key.Interface())
+}
@@ -0,0 +1,117 @@
+package desensitivize
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollisionPolicy(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	defer SetCollisionPolicy(CollisionOverwrite)
+
+	type (
+		MapKey struct {
+			F1 string `sensitive:"-"`
+			F2 string
+		}
+
+		RedactStruct struct {
+			Keys   map[MapKey]int
+			Tagged map[string]int `sensitive:"keys,key"`
+		}
+	)
+
+	SetCustomRedact("key", "key")
+
+	obj := RedactStruct{
+		Keys: map[MapKey]int{
+			{F1: "a", F2: "x"}: 1,
+			{F1: "b", F2: "x"}: 2,
+			{F1: "c", F2: "y"}: 3,
+		},
+		Tagged: map[string]int{
+			"a": 1,
+			"b": 2,
+			"c": 3,
+		},
+	}
+
+	tests := []struct {
+		policy   CollisionPolicy
+		expected RedactStruct
+		merged   []Collision
+		err      error
+	}{
+		{
+			policy: CollisionOverwrite,
+			expected: RedactStruct{
+				Keys:   map[MapKey]int{{F2: "x"}: 2, {F2: "y"}: 3},
+				Tagged: map[string]int{"key": 3},
+			},
+		},
+		{
+			policy: CollisionKeepFirst,
+			expected: RedactStruct{
+				Keys:   map[MapKey]int{{F2: "x"}: 1, {F2: "y"}: 3},
+				Tagged: map[string]int{"key": 1},
+			},
+		},
+		{
+			policy: CollisionError,
+			expected: RedactStruct{
+				Keys:   map[MapKey]int{{F2: "x"}: 1, {F2: "y"}: 3},
+				Tagged: map[string]int{"key": 1},
+			},
+			err: ErrKeyCollision,
+		},
+		{
+			policy: CollisionMerge,
+			expected: RedactStruct{
+				Keys:   map[MapKey]int{{F2: "x"}: 1, {F2: "y"}: 3},
+				Tagged: map[string]int{"key": 1},
+			},
+			merged: []Collision{
+				{Key: MapKey{F2: "x"}, Values: []any{1, 2}},
+				{Key: "key", Values: []any{1, 2, 3}},
+			},
+		},
+		{
+			policy: CollisionSuffix,
+			expected: RedactStruct{
+				Keys:   map[MapKey]int{{F2: "x"}: 1, {F2: "y"}: 3},
+				Tagged: map[string]int{"key": 1, "key#1": 2, "key#2": 3},
+			},
+			merged: []Collision{
+				{Key: MapKey{F2: "x"}, Values: []any{1, 2}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		SetCollisionPolicy(test.policy)
+
+		redacted, stats, err := TryRedact(obj)
+		require.Equal(t, test.expected, redacted)
+		require.Equal(t, 3, stats.Collisions)
+		require.Equal(t, test.merged, stats.Merged)
+		require.True(t, errors.Is(err, test.err))
+	}
+}
+
+func TestCollisionSuffixNonStringKeys(t *testing.T) {
+	defer SetCollisionPolicy(CollisionOverwrite)
+	SetCollisionPolicy(CollisionSuffix)
+
+	type RedactStruct struct {
+		IDs map[int]string `sensitive:"keys"`
+	}
+
+	redacted, stats, err := TryRedact(RedactStruct{IDs: map[int]string{3: "c", 1: "a", 2: "b"}})
+	require.NoError(t, err)
+	require.Equal(t, RedactStruct{IDs: map[int]string{0: "a"}}, redacted)
+	require.Equal(t, 2, stats.Collisions)
+	require.Equal(t, []Collision{{Key: 0, Values: []any{"a", "b", "c"}}}, stats.Merged)
+}
@@ -0,0 +1,129 @@
+package desensitivize
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type condOp int
+
+const (
+	condPredicate condOp = iota
+	condEqual
+	condNotEqual
+	condIn
+	condNotIn
+)
+
+type condition struct {
+	op     condOp
+	field  string
+	values []string
+}
+
+var predicates map[string]func(parent reflect.Value) bool
+
+func init() {
+	predicates = map[string]func(parent reflect.Value) bool{}
+}
+
+// RegisterPredicate makes fn usable as a condition, e.g. `sensitive:"if=isEU"`.
+// fn receives the struct enclosing the tagged field.
+func RegisterPredicate(name string, fn func(parent reflect.Value) bool) {
+	predicates[name] = fn
+	invalidatePlans()
+}
+
+func parseCondition(expr string) (condition, error) {
+	expr = strings.TrimSpace(expr)
+
+	for _, op := range []struct {
+		token string
+		op    condOp
+	}{
+		{" not in ", condNotIn},
+		{" in ", condIn},
+		{"!=", condNotEqual},
+		{"==", condEqual},
+	} {
+		field, values, found := strings.Cut(expr, op.token)
+		if !found {
+			continue
+		}
+
+		cond := condition{
+			op:    op.op,
+			field: strings.TrimSpace(field),
+		}
+
+		if op.op == condIn || op.op == condNotIn {
+			for _, value := range strings.Split(values, ",") {
+				cond.values = append(cond.values, unquote(strings.TrimSpace(value)))
+			}
+		} else {
+			cond.values = []string{unquote(strings.TrimSpace(values))}
+		}
+
+		if cond.field == "" {
+			return condition{}, fmt.Errorf("desensitivize: condition %q has no field", expr)
+		}
+
+		return cond, nil
+	}
+
+	if _, exist := predicates[expr]; !exist {
+		return condition{}, fmt.Errorf("desensitivize: unknown predicate %q", expr)
+	}
+
+	return condition{
+		op:    condPredicate,
+		field: expr,
+	}, nil
+}
+
+func (c condition) eval(parent reflect.Value) (bool, error) {
+	if c.op == condPredicate {
+		return predicates[c.field](parent), nil
+	}
+
+	fieldVal := parent.FieldByName(c.field)
+	if !fieldVal.IsValid() {
+		return false, fmt.Errorf("desensitivize: condition field %q not found in %s", c.field, parent.Type())
+	}
+
+	for fieldVal.Kind() == reflect.Pointer || fieldVal.Kind() == reflect.Interface {
+		if fieldVal.IsNil() {
+			break
+		}
+		fieldVal = fieldVal.Elem()
+	}
+
+	value := ""
+	if fieldVal.Kind() != reflect.Pointer && fieldVal.Kind() != reflect.Interface {
+		value = fmt.Sprint(fieldVal)
+	}
+
+	matched := false
+	for _, expected := range c.values {
+		if value == expected {
+			matched = true
+			break
+		}
+	}
+
+	if c.op == condNotEqual || c.op == condNotIn {
+		return !matched, nil
+	}
+
+	return matched, nil
+}
+
+func unquote(value string) string {
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+
+	return value
+}
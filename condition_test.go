@@ -0,0 +1,93 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalRedact(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	predicates = map[string]func(parent reflect.Value) bool{}
+
+	type (
+		Payload struct {
+			Data   string
+			Secret string `sensitive:"-"`
+		}
+
+		Customer struct {
+			Country  string `sensitive:"-"`
+			Kind     *string
+			TaxID    string  `sensitive:"if=Country in DE,FR"`
+			VatID    string  `sensitive:"masked,if=Country not in US, GB"`
+			Payload  Payload `sensitive:"if=Kind == \"credentials\""`
+			Comment  string  `sensitive:"if=Kind != credentials"`
+			Internal string  `sensitive:"if=internal"`
+		}
+	)
+
+	SetCustomRedact("masked", "****")
+	RegisterPredicate("internal", func(parent reflect.Value) bool {
+		return parent.FieldByName("Country").String() == "DE"
+	})
+
+	obj := Customer{
+		Country: "DE",
+		Kind:    vToP("credentials"),
+		TaxID:   "tax",
+		VatID:   "vat",
+		Payload: Payload{
+			Data:   "data",
+			Secret: "secret",
+		},
+		Comment:  "comment",
+		Internal: "internal",
+	}
+
+	redacted, _, err := TryRedact(obj)
+	require.Nil(t, err)
+	require.Equal(t, Customer{
+		Kind:    vToP("credentials"),
+		VatID:   "****",
+		Comment: "comment",
+	}, redacted)
+
+	obj.Country = "US"
+	obj.Kind = nil
+
+	redacted, _, err = TryRedact(obj)
+	require.Nil(t, err)
+	require.Equal(t, Customer{
+		TaxID: "tax",
+		VatID: "vat",
+		Payload: Payload{
+			Data: "data",
+		},
+		Internal: "internal",
+	}, redacted)
+}
+
+func TestInvalidCondition(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	predicates = map[string]func(parent reflect.Value) bool{}
+
+	type (
+		UnknownField struct {
+			F1 string `sensitive:"if=Missing == 1"`
+		}
+
+		UnknownPredicate struct {
+			F1 string `sensitive:"if=missing"`
+		}
+	)
+
+	redacted, _, err := TryRedact(UnknownField{F1: "F1"})
+	require.NotNil(t, err)
+	require.Equal(t, UnknownField{}, redacted)
+
+	redactedPredicate, _, err := TryRedact(UnknownPredicate{F1: "F1"})
+	require.NotNil(t, err)
+	require.Equal(t, UnknownPredicate{}, redactedPredicate)
+}
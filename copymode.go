@@ -0,0 +1,122 @@
+package desensitivize
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// CopyMode decides how Redact keeps the original value untouched.
+type CopyMode int
+
+const (
+	// CopyDeep copies the whole value before redacting it. The result shares
+	// nothing but functions, channels, pointers to structs with only
+	// unexported fields and nothing to redact, such as errors, and the parts
+	// of HTTP requests and responses which aren't redacted, such as bodies,
+	// with the original.
+	CopyDeep CopyMode = iota
+	// CopyOnWrite copies only the structs, slices, maps, arrays, pointers and
+	// interfaces on the way to redacted data. Everything else is shared with
+	// the original, so mutating the result may mutate the original as well.
+	CopyOnWrite
+)
+
+var copyMode CopyMode
+
+func SetCopyMode(mode CopyMode) {
+	copyMode = mode
+}
+
+type copiedPointer struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// deepCopy copies v including unexported fields. Pointers to the same value
+// stay shared in the copy, which keeps the copy of a cyclic value finite. The
+// walkers follow such cycles only once, see handleCyclicPointer.
+func deepCopy(v reflect.Value, copied map[copiedPointer]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+
+		if planFor(v.Type().Elem()).opaque {
+			return v
+		}
+
+		key := copiedPointer{addr: v.Pointer(), typ: v.Type()}
+		if ptr, exist := copied[key]; exist {
+			return ptr
+		}
+
+		ptr := reflect.New(v.Type().Elem())
+		copied[key] = ptr
+		ptr.Elem().Set(deepCopy(v.Elem(), copied))
+		return ptr
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		iface := reflect.New(v.Type()).Elem()
+		iface.Set(deepCopy(v.Elem(), copied))
+		return iface
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		slice := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			slice.Index(i).Set(deepCopy(v.Index(i), copied))
+		}
+		return slice
+	case reflect.Array:
+		array := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			array.Index(i).Set(deepCopy(v.Index(i), copied))
+		}
+		return array
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(deepCopy(iter.Key(), copied), deepCopy(iter.Value(), copied))
+		}
+		return m
+	case reflect.Struct:
+		if fields, exist := handledFields[v.Type()]; exist {
+			st := reflect.New(v.Type()).Elem()
+			st.Set(v)
+			for _, name := range fields {
+				st.FieldByName(name).Set(deepCopy(v.FieldByName(name), copied))
+			}
+			return st
+		}
+
+		if !v.CanAddr() {
+			addressable := reflect.New(v.Type()).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+
+		st := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			unexportedField(st.Field(i)).Set(deepCopy(unexportedField(v.Field(i)), copied))
+		}
+		return st
+	}
+
+	return v
+}
+
+// unexportedField makes an addressable field usable even if it's unexported.
+func unexportedField(field reflect.Value) reflect.Value {
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+}
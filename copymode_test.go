@@ -0,0 +1,196 @@
+package desensitivize
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyOnWrite(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	SetCopyMode(CopyOnWrite)
+	defer SetCopyMode(CopyDeep)
+
+	type (
+		Secret struct {
+			Token string `sensitive:"-"`
+			Note  string
+		}
+
+		Response struct {
+			Secrets  []Secret
+			ByName   map[string]*Secret
+			Keyed    map[Secret]int
+			Pair     [2]Secret
+			Any      any
+			Tags     []string
+			Labels   map[string]string
+			Untagged []Secret
+			hidden   *Secret
+			counter  int
+		}
+	)
+
+	obj := Response{
+		Secrets: []Secret{{Token: "a", Note: "x"}},
+		ByName:  map[string]*Secret{"b": {Token: "b", Note: "y"}},
+		Keyed:   map[Secret]int{{Token: "c", Note: "z"}: 1},
+		Pair:    [2]Secret{{Token: "d"}, {Token: "e"}},
+		Any:     &Secret{Token: "f"},
+		Tags:    []string{"t"},
+		Labels:  map[string]string{"l": "v"},
+		hidden:  &Secret{Token: "g"},
+		counter: 7,
+	}
+	snapshot := obj
+	secret := *obj.ByName["b"]
+
+	redacted, _, err := TryRedact(obj)
+	require.NoError(t, err)
+
+	require.Equal(t, []Secret{{Note: "x"}}, redacted.Secrets)
+	require.Equal(t, map[string]*Secret{"b": {Note: "y"}}, redacted.ByName)
+	require.Equal(t, map[Secret]int{{Note: "z"}: 1}, redacted.Keyed)
+	require.Equal(t, [2]Secret{}, redacted.Pair)
+	require.Equal(t, &Secret{}, redacted.Any)
+	require.Nil(t, redacted.hidden)
+	require.Equal(t, 7, redacted.counter)
+
+	// The original is never mutated.
+	require.Equal(t, snapshot, obj)
+	require.Equal(t, "a", obj.Secrets[0].Token)
+	require.Equal(t, secret, *obj.ByName["b"])
+	require.Equal(t, map[Secret]int{{Token: "c", Note: "z"}: 1}, obj.Keyed)
+	require.Equal(t, "f", obj.Any.(*Secret).Token)
+	require.Equal(t, "g", obj.hidden.Token)
+
+	// Untouched values are shared.
+	require.Equal(t, reflect.ValueOf(obj.Tags).Pointer(), reflect.ValueOf(redacted.Tags).Pointer())
+	require.Equal(t, reflect.ValueOf(obj.Labels).Pointer(), reflect.ValueOf(redacted.Labels).Pointer())
+	require.Nil(t, redacted.Untagged)
+}
+
+func TestCopyOnWriteSharesUnchangedContainers(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	SetCopyMode(CopyOnWrite)
+	defer SetCopyMode(CopyDeep)
+
+	type Secret struct {
+		Token string `sensitive:"-"`
+	}
+
+	secrets := []*Secret{nil, nil}
+	byName := map[string]*Secret{"a": nil}
+	ptr := &[]*Secret{nil}
+
+	require.Equal(t, reflect.ValueOf(secrets).Pointer(), reflect.ValueOf(Redact(secrets)).Pointer())
+	require.Equal(t, reflect.ValueOf(byName).Pointer(), reflect.ValueOf(Redact(byName)).Pointer())
+	require.Same(t, ptr, Redact(ptr))
+}
+
+func TestUnexportedFields(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	defer SetCopyMode(CopyDeep)
+
+	type (
+		Secret struct {
+			Token string `sensitive:"-"`
+		}
+
+		Result struct {
+			Token  string `sensitive:"-"`
+			err    error
+			hidden *Secret
+			value  any
+		}
+	)
+
+	for _, mode := range []CopyMode{CopyDeep, CopyOnWrite} {
+		SetCopyMode(mode)
+
+		obj := Result{
+			Token:  "t",
+			err:    fmt.Errorf("parse: %w", fmt.Errorf("read: %w", io.EOF)),
+			hidden: &Secret{Token: "s"},
+			value:  &Secret{Token: "v"},
+		}
+
+		redacted, report := RedactWithReport(obj)
+		require.Empty(t, redacted.Token)
+		require.Nil(t, redacted.hidden)
+		require.Nil(t, redacted.value)
+		require.True(t, errors.Is(redacted.err, io.EOF))
+		require.Equal(t, "v", obj.value.(*Secret).Token)
+		require.Equal(t, []Redaction{
+			{Path: "Token", Tag: "-", Strategy: "-", Type: "string"},
+			{Path: "hidden", Strategy: "unexported", Type: "*desensitivize.Secret"},
+			{Path: "value", Strategy: "unexported", Type: "interface {}"},
+		}, report.Redactions)
+	}
+
+	require.True(t, NeedsRedaction(&Secret{Token: "s"}))
+	require.False(t, NeedsRedaction(fmt.Errorf("read: %w", io.EOF)))
+	require.False(t, NeedsRedaction(nil))
+	require.Equal(t, `desensitivize.Result
+  Token string: redact using "-"
+  hidden *desensitivize.Secret: zero
+`, Explain[Result]().String())
+}
+
+func TestCyclicValues(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	defer SetCopyMode(CopyDeep)
+
+	type Node struct {
+		Secret string `sensitive:"mask"`
+		Name   string
+		Next   *Node
+		Any    any
+	}
+
+	newCycle := func() *Node {
+		first := &Node{Secret: "first", Name: "a"}
+		second := &Node{Secret: "second", Name: "b", Next: first}
+		first.Next = second
+		first.Any = first
+		return first
+	}
+
+	for _, mode := range []CopyMode{CopyDeep, CopyOnWrite} {
+		SetCopyMode(mode)
+
+		obj := newCycle()
+		redacted, report := RedactWithReport(obj)
+
+		require.Equal(t, "*****", redacted.Secret)
+		require.Equal(t, "******", redacted.Next.Secret)
+		require.Same(t, redacted, redacted.Next.Next)
+		require.Same(t, redacted, redacted.Any)
+		require.Len(t, report.Redactions, 2)
+		require.Equal(t, "first", obj.Secret)
+		require.Equal(t, "second", obj.Next.Secret)
+	}
+
+	obj := newCycle()
+	require.NoError(t, RedactInPlace(&obj))
+	require.Equal(t, "*****", obj.Secret)
+	require.Equal(t, "******", obj.Next.Secret)
+}
+
+func BenchmarkRedactCopyOnWrite(b *testing.B) {
+	SetCopyMode(CopyOnWrite)
+	defer SetCopyMode(CopyDeep)
+
+	response := benchResponseValue()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Redact(response)
+	}
+}
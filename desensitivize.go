@@ -1,9 +1,8 @@
 package desensitivize
 
 import (
-	"bytes"
-	"encoding/gob"
 	"reflect"
+	"unsafe"
 )
 
 type redactMeta struct {
@@ -11,7 +10,10 @@ type redactMeta struct {
 	specificVals map[string]reflect.Value
 }
 
-var customRedacts map[reflect.Type]redactMeta
+var (
+	customRedacts map[reflect.Type]redactMeta
+	redactHooks   []func(Event)
+)
 
 func init() {
 	customRedacts = map[reflect.Type]redactMeta{}
@@ -48,229 +50,637 @@ func SetCustomRedact[T any](key string, val T) {
 }
 
 func copyObj[T any](obj T) (objCopy T) {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	dec := gob.NewDecoder(&buf)
+	copied := deepCopy(reflect.ValueOf(&obj).Elem(), map[copiedPointer]reflect.Value{})
+	reflect.ValueOf(&objCopy).Elem().Set(copied)
+	return
+}
 
-	enc.Encode(obj)
-	dec.Decode(&objCopy)
+// Stats describes a single redaction.
+type Stats struct {
+	// Collisions counts the map entries whose redacted key was already taken.
+	Collisions int
+	// Merged holds the colliding values per key under CollisionMerge.
+	Merged []Collision
+	// Placeholders lists the values zeroed by the placeholder strategy.
+	Placeholders []Placeholder
+}
 
-	return
+type redactor struct {
+	stats Stats
+	err   error
+	path  []pathSegment
+	// root is where the path of an embedded document starts.
+	root int
+
+	// inPlace makes the handlers modify their input instead of copying it.
+	inPlace bool
+
+	recording  bool
+	tag        string
+	redactions []Redaction
+
+	// visited remembers the pointers of cyclic types being or having been
+	// redacted, so cycles are followed only once.
+	visited map[copiedPointer]*visitedPointer
+
+	// probing redactors only find out whether anything would be redacted,
+	// without reporting it. probe is the one used by r.
+	probing bool
+	probe   *redactor
+}
+
+type visitedPointer struct {
+	// redacted is the pointer the original one is replaced with.
+	redacted reflect.Value
+	changed  bool
+	// revisited tells whether redacted was handed out before the value it
+	// points to was redacted.
+	revisited bool
+	done      bool
+}
+
+func (r *redactor) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
 }
 
 func Redact[T any](obj T) T {
-	objCopy := copyObj(obj)
+	redacted, _, _ := TryRedact(obj)
+	return redacted
+}
+
+// TryRedact works like Redact and additionally reports what happened during
+// the redaction, failing when the configured CollisionPolicy says so.
+func TryRedact[T any](obj T) (T, Stats, error) {
+	r := &redactor{}
+	redacted := redact(r, obj)
+	return redacted, r.stats, r.err
+}
+
+// NeedsRedaction tells whether Redact would change anything in obj. Methods
+// generated by desensitivize-gen use it for unexported interface fields, which
+// are zeroed then.
+func NeedsRedaction(obj any) bool {
+	if obj == nil {
+		return false
+	}
+
+	r := &redactor{}
+	return r.needsRedaction(reflect.ValueOf(obj))
+}
+
+// needsRedaction walks obj without modifying or reporting anything. The probe
+// is kept for the whole redaction, so cycles through unexported interfaces are
+// followed only once.
+func (r *redactor) needsRedaction(obj reflect.Value) bool {
+	probe := r
+	if !r.probing {
+		if r.probe == nil {
+			r.probe = &redactor{probing: true}
+		}
+		probe = r.probe
+	}
 
-	objValue := reflect.ValueOf(objCopy)
-	if !objValue.IsValid() {
+	_, changed := probe.handleValue(obj)
+	return changed
+}
+
+func redact[T any](r *redactor, obj T) T {
+	if !planFor(reflect.TypeOf((*T)(nil)).Elem()).sensitive {
 		return obj
 	}
 
-	objType := objValue.Type()
-	objKind := objType.Kind()
+	return redactSensitive(r, obj)
+}
+
+func redactSensitive[T any](r *redactor, obj T) T {
+	objValue := reflect.ValueOf(&obj).Elem()
+
+	if copyMode == CopyDeep {
+		objCopy := copyObj(obj)
+		objValue = reflect.ValueOf(&objCopy).Elem()
+		r.inPlace = true
+	}
+
+	redacted, _ := r.handleValue(objValue)
+
+	var result T
+	reflect.ValueOf(&result).Elem().Set(redacted)
+	return result
+}
+
+// The handlers below return obj itself and false when nothing underneath it
+// was redacted, or the redacted value and true otherwise. Unless redacting in
+// place they never modify obj and copy only what changed, so untouched values
+// stay shared with the original.
+
+func (r *redactor) handleValue(obj reflect.Value) (reflect.Value, bool) {
+	plan := planFor(obj.Type())
+	if !plan.sensitive {
+		return obj, false
+	}
 
-	switch objKind {
+	if plan.handler != nil {
+		return plan.handler(r, obj)
+	}
+
+	switch obj.Kind() {
 	case reflect.Struct:
-		return *handleStruct(objValue).Interface().(*T)
+		return r.handleStruct(obj)
 	case reflect.Pointer:
-		return handlePointer(objValue).Interface().(T)
+		return r.handlePointer(obj)
+	case reflect.Interface:
+		return r.handleInterface(obj)
 	case reflect.Slice:
-		return handleSlice(objValue).Interface().(T)
+		return r.handleSlice(obj)
 	case reflect.Map:
-		return handleMap(objValue).Interface().(T)
+		return r.handleMap(obj)
 	case reflect.Array:
-		return *handleArray(objValue).Interface().(*T)
+		return r.handleArray(obj)
 	}
 
-	return obj
+	return obj, false
 }
 
-func handleSlice(obj reflect.Value) reflect.Value {
-	if obj.Kind() == reflect.Pointer {
-		obj = obj.Elem()
+func (r *redactor) handleSlice(obj reflect.Value) (reflect.Value, bool) {
+	redacted := obj
+	changed := false
+
+	for i := 0; i < obj.Len(); i++ {
+		r.enterIndex(i)
+		elem, elemChanged := r.handleValue(obj.Index(i))
+		r.leave()
+
+		if !elemChanged {
+			continue
+		}
+
+		if !changed && !r.inPlace {
+			redacted = reflect.MakeSlice(obj.Type(), obj.Len(), obj.Len())
+			reflect.Copy(redacted, obj)
+		}
+		changed = true
+
+		redacted.Index(i).Set(elem)
 	}
 
+	return redacted, changed
+}
+
+func (r *redactor) handleArray(obj reflect.Value) (reflect.Value, bool) {
+	redacted := obj
+	changed := false
+
 	for i := 0; i < obj.Len(); i++ {
-		indexVal := obj.Index(i)
-		indexKind := indexVal.Kind()
-
-		switch indexKind {
-		case reflect.Struct:
-			indexVal.Set(handleStruct(indexVal).Elem())
-		case reflect.Pointer:
-			indexVal.Elem().Set(handlePointer(indexVal).Elem())
-		case reflect.Slice:
-			indexVal.Set(handleSlice(indexVal))
-		case reflect.Map:
-			indexVal.Set(handleMap(indexVal))
-		case reflect.Array:
-			indexVal.Set(handleArray(indexVal).Elem())
+		r.enterIndex(i)
+		elem, elemChanged := r.handleValue(obj.Index(i))
+		r.leave()
+
+		if !elemChanged {
+			continue
+		}
+
+		if !changed && !r.owns(obj) {
+			redacted = reflect.New(obj.Type()).Elem()
+			redacted.Set(obj)
 		}
+		changed = true
+
+		redacted.Index(i).Set(elem)
 	}
 
-	return obj
+	return redacted, changed
 }
 
-func handleMap(obj reflect.Value) reflect.Value {
-	if obj.Kind() == reflect.Pointer {
-		obj = obj.Elem()
+// owns tells whether obj may be modified directly.
+func (r *redactor) owns(obj reflect.Value) bool {
+	return r.inPlace && obj.CanSet()
+}
+
+func (r *redactor) handleMap(obj reflect.Value) (reflect.Value, bool) {
+	if obj.IsNil() {
+		return obj, false
+	}
+
+	keySensitive := planFor(obj.Type().Key()).sensitive
+	matchKeys := keyRulesApply(obj.Type())
+	entries := make([]mapEntry, 0, obj.Len())
+	keysChanged, changed := false, false
+
+	iter := obj.MapRange()
+	for iter.Next() {
+		key, keyChanged := iter.Key(), false
+		if keySensitive {
+			r.enterMapKey()
+			key, keyChanged = r.handleValue(key)
+			r.leave()
+		}
+
+		r.enterKey(key)
+		elem, elemChanged := r.handleEntry(key, iter.Value(), matchKeys)
+		r.leave()
+
+		keysChanged = keysChanged || keyChanged
+		changed = changed || keyChanged || elemChanged
+
+		entries = append(entries, mapEntry{
+			origKey: iter.Key(),
+			key:     key,
+			elem:    elem,
+		})
 	}
 
-	keys := obj.MapKeys()
-	for _, key := range keys {
-		elem := obj.MapIndex(key)
-		elemKind := elem.Kind()
-		keyKind := key.Kind()
-		obj.SetMapIndex(key, reflect.Value{})
-		key = handleMapKey(key)
+	if !changed {
+		return obj, false
+	}
+
+	if keysChanged {
+		return r.storeMap(obj, r.buildRedactedMap(obj.Type(), entries)), true
+	}
 
-		if key.Kind() == reflect.Pointer && keyKind != reflect.Pointer {
-			key = key.Elem()
+	if r.inPlace {
+		for _, entry := range entries {
+			obj.SetMapIndex(entry.key, entry.elem)
 		}
+		return obj, true
+	}
+
+	redactedMap := reflect.MakeMapWithSize(obj.Type(), len(entries))
+	for _, entry := range entries {
+		redactedMap.SetMapIndex(entry.key, entry.elem)
+	}
 
-		switch elemKind {
-		case reflect.Struct:
-			obj.SetMapIndex(key, handleStruct(elem).Elem())
-		case reflect.Pointer:
-			obj.SetMapIndex(key, handlePointer(elem))
-		case reflect.Slice:
-			obj.SetMapIndex(key, handleSlice(elem))
-		case reflect.Map:
-			obj.SetMapIndex(key, handleMap(elem))
-		case reflect.Array:
-			obj.SetMapIndex(key, handleArray(elem).Elem())
+	return redactedMap, true
+}
+
+// handleEntry redacts the value of a map entry, applying the key rules to
+// string keys when matchKeys is set.
+func (r *redactor) handleEntry(key, elem reflect.Value, matchKeys bool) (reflect.Value, bool) {
+	if matchKeys {
+		if rule, matched := matchKey(keyRules, key.String()); matched {
+			return r.redactDynamic(elem, rule.strategy), true
 		}
 	}
-	return obj
+
+	return r.handleValue(elem)
 }
 
-func handlePointer(obj reflect.Value) reflect.Value {
+func (r *redactor) handleTaggedMap(obj reflect.Value, spec tagSpec) reflect.Value {
 	if obj.IsNil() {
 		return obj
 	}
 
 	objType := obj.Type()
-	objKind := objType.Elem().Kind()
 
-	switch objKind {
-	case reflect.Struct:
-		return handleStruct(obj.Elem())
-	case reflect.Pointer:
-		redactedValue := handlePointer(obj.Elem())
-		tmpObj := reflect.New(redactedValue.Type())
-		tmpObj.Elem().Set(redactedValue)
-		return tmpObj
-	case reflect.Slice:
-		redactedValue := handleSlice(obj)
-		tmpObj := reflect.New(objType.Elem())
-		tmpObj.Elem().Set(redactedValue)
-		return tmpObj
-	case reflect.Map:
-		redactedValue := handleMap(obj)
-		tmpObj := reflect.New(objType.Elem())
-		tmpObj.Elem().Set(redactedValue)
-		return tmpObj
-	case reflect.Array:
-		redactedValue := handleArray(obj.Elem())
-		tmpObj := reflect.New(objType.Elem())
-		tmpObj.Elem().Set(redactedValue.Elem())
-		return tmpObj
+	if spec.scope == scopeKeys {
+		entries := make([]mapEntry, 0, obj.Len())
+
+		iter := obj.MapRange()
+		for iter.Next() {
+			r.enterMapKey()
+			key := r.redactValue(iter.Key(), spec.strategy)
+			r.leave()
+
+			r.enterKey(key)
+			elem, _ := r.handleValue(iter.Value())
+			entries = append(entries, mapEntry{
+				origKey: iter.Key(),
+				key:     key,
+				elem:    elem,
+			})
+			r.leave()
+		}
+
+		return r.storeMap(obj, r.buildRedactedMap(objType, entries))
+	}
+
+	redactedMap := reflect.MakeMapWithSize(objType, obj.Len())
+
+	iter := obj.MapRange()
+	for iter.Next() {
+		key, elem := iter.Key(), iter.Value()
+		r.enterKey(key)
+
+		if spec.scope == scopeValues || spec.matchesEntry(key) {
+			elem = r.redactValue(elem, spec.strategy)
+		} else {
+			elem, _ = r.handleValue(elem)
+		}
+		redactedMap.SetMapIndex(key, elem)
+
+		r.leave()
+	}
+
+	return r.storeMap(obj, redactedMap)
+}
+
+// storeMap returns redacted, or when redacting in place replaces the
+// entries of obj with the ones of redacted and returns obj.
+func (r *redactor) storeMap(obj, redacted reflect.Value) reflect.Value {
+	if !r.inPlace {
+		return redacted
+	}
+
+	iter := obj.MapRange()
+	for iter.Next() {
+		obj.SetMapIndex(iter.Key(), reflect.Value{})
+	}
+
+	iter = redacted.MapRange()
+	for iter.Next() {
+		obj.SetMapIndex(iter.Key(), iter.Value())
 	}
 
 	return obj
 }
 
-func handleMapKey(key reflect.Value) reflect.Value {
-	typeOf := key.Type()
-	kind := typeOf.Kind()
+func (r *redactor) handlePointer(obj reflect.Value) (reflect.Value, bool) {
+	if obj.IsNil() {
+		return obj, false
+	}
 
-	switch kind {
-	case reflect.Struct:
-		return handleStruct(key)
-	case reflect.Array:
-		return handleArray(key)
-	case reflect.Pointer:
-		return handlePointer(key)
+	if planFor(obj.Type()).cyclic {
+		return r.handleCyclicPointer(obj)
+	}
+
+	elem, changed := r.handleValue(obj.Elem())
+	if !changed {
+		return obj, false
 	}
-	return key
+
+	if r.inPlace {
+		obj.Elem().Set(elem)
+		return obj, true
+	}
+
+	redacted := reflect.New(obj.Type().Elem())
+	redacted.Elem().Set(elem)
+	return redacted, true
 }
 
-func handleArray(obj reflect.Value) reflect.Value {
-	tempArr := reflect.New(reflect.ArrayOf(obj.Len(), obj.Index(0).Type()))
-	for i := 0; i < obj.Len(); i++ {
-		element := obj.Index(i)
-		newEl := tempArr.Elem().Index(i)
-		elementKind := element.Kind()
-
-		switch elementKind {
-		case reflect.Struct:
-			newEl.Set(handleStruct(element).Elem())
-		case reflect.Array:
-			newEl.Set(handleArray(element).Elem())
-		case reflect.Map:
-			newEl.Set(handleMap(element))
-		case reflect.Pointer:
-			newEl.Set(handlePointer(element))
-		case reflect.Slice:
-			newEl.Set(handleSlice(element))
+// handleCyclicPointer redacts the target of obj once, however often it is
+// reached. A pointer reached again while its target is still being redacted
+// is replaced with a new one which is filled in afterwards.
+func (r *redactor) handleCyclicPointer(obj reflect.Value) (reflect.Value, bool) {
+	key := copiedPointer{addr: obj.Pointer(), typ: obj.Type()}
+	if visited, exist := r.visited[key]; exist {
+		if !visited.done && !r.inPlace {
+			visited.revisited = true
+			return visited.redacted, true
+		}
+		return visited.redacted, visited.changed
+	}
+
+	if r.visited == nil {
+		r.visited = map[copiedPointer]*visitedPointer{}
+	}
+
+	visited := &visitedPointer{redacted: obj}
+	if !r.inPlace {
+		visited.redacted = reflect.New(obj.Type().Elem())
+	}
+	r.visited[key] = visited
+
+	elem, changed := r.handleValue(obj.Elem())
+
+	switch {
+	case r.inPlace:
+		if changed {
+			obj.Elem().Set(elem)
 		}
+	case changed || visited.revisited:
+		visited.redacted.Elem().Set(elem)
+		changed = true
+	default:
+		visited.redacted = obj
 	}
 
-	return tempArr
+	visited.changed, visited.done = changed, true
+	return visited.redacted, changed
 }
 
-func handleStruct(obj reflect.Value) reflect.Value {
-	if obj.Type().Kind() != reflect.Ptr {
-		tmpObj := reflect.New(obj.Type())
+func (r *redactor) handleInterface(obj reflect.Value) (reflect.Value, bool) {
+	if obj.IsNil() {
+		return obj, false
+	}
 
-		tmpObj.Elem().Set(obj)
+	elem, changed := r.handleValue(obj.Elem())
+	if !changed {
+		return obj, false
+	}
 
-		obj = tmpObj
+	redacted := reflect.New(obj.Type()).Elem()
+	redacted.Set(elem)
+	return redacted, true
+}
+
+func (r *redactor) handleStruct(obj reflect.Value) (reflect.Value, bool) {
+	plan := planFor(obj.Type())
+	redacted := obj
+	changed := false
+
+	// Conditions are evaluated against the original field values.
+	parent := obj
+	if plan.conditional && r.owns(obj) {
+		parent = reflect.New(obj.Type()).Elem()
+		parent.Set(obj)
 	}
 
-	objType := obj.Type()
-	for i := 0; i < objType.Elem().NumField(); i++ {
-		fieldVal := obj.Elem().Field(i)
+	ensureCopy := func() {
+		if !changed && !r.owns(obj) {
+			redacted = reflect.New(obj.Type()).Elem()
+			redacted.Set(obj)
+		}
+		changed = true
+	}
 
-		if !fieldVal.CanSet() {
-			continue
+	for _, field := range plan.fields {
+		r.enterField(field.name)
+		fieldVal, fieldChanged := r.handleField(obj.Field(field.index), field, parent)
+		r.leave()
+
+		if fieldChanged {
+			ensureCopy()
+			redacted.Field(field.index).Set(fieldVal)
 		}
+	}
 
-		if tag, exist := objType.Elem().Field(i).Tag.Lookup("sensitive"); exist {
-			fieldType := fieldVal.Type()
-			var setVal reflect.Value
-			meta, exists := customRedacts[fieldType]
-			if !exists {
-				setVal = reflect.Zero(fieldType)
-			}
+	// Unexported fields can't be walked, so the ones leading to tags are
+	// dropped.
+	for _, index := range plan.unexported {
+		fieldVal := obj.Field(index)
+		if fieldVal.IsZero() {
+			continue
+		}
 
-			specificValue, exists := meta.specificVals[tag]
-			if !exists && meta.defaultVal != (reflect.Value{}) {
-				setVal = meta.defaultVal
-			}
+		r.enterField(obj.Type().Field(index).Name)
+		r.record(fieldVal.Type(), unexportedStrategy)
+		r.leave()
 
-			if exists {
-				setVal = specificValue
-			}
+		ensureCopy()
+		zeroField(redacted.Field(index))
+	}
 
-			fieldVal.Set(setVal)
+	for _, index := range plan.dynamic {
+		if obj.Field(index).IsNil() || !r.needsRedaction(readField(obj, index).Elem()) {
 			continue
 		}
 
-		fieldKind := fieldVal.Kind()
-		switch fieldKind {
-		case reflect.Struct:
-			fieldVal.Set(handleStruct(fieldVal).Elem())
-		case reflect.Slice:
-			fieldVal.Set(handleSlice(fieldVal))
-		case reflect.Map:
-			fieldVal.Set(handleMap(fieldVal))
-		case reflect.Pointer:
-			fieldVal.Set(handlePointer(fieldVal))
-		case reflect.Array:
-			fieldVal.Set(handleArray(fieldVal).Elem())
+		r.enterField(obj.Type().Field(index).Name)
+		r.record(obj.Field(index).Type(), unexportedStrategy)
+		r.leave()
+
+		ensureCopy()
+		zeroField(redacted.Field(index))
+	}
+
+	return redacted, changed
+}
+
+// unexportedStrategy is reported for zeroed unexported fields.
+const unexportedStrategy = "unexported"
+
+// readField returns the unexported field index of obj in a usable form.
+func readField(obj reflect.Value, index int) reflect.Value {
+	if !obj.CanAddr() {
+		objCopy := reflect.New(obj.Type()).Elem()
+		objCopy.Set(obj)
+		obj = objCopy
+	}
+
+	return unexportedField(obj.Field(index))
+}
+
+func zeroField(field reflect.Value) {
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Set(reflect.Zero(field.Type()))
+}
+
+func (r *redactor) handleField(fieldVal reflect.Value, field fieldPlan, parent reflect.Value) (reflect.Value, bool) {
+	if field.tagged && r.conditionHolds(field, parent) {
+		prevTag := r.tag
+		r.tag = field.tag
+		redacted := r.redactField(fieldVal, field.spec)
+		r.tag = prevTag
+		return redacted, true
+	}
+
+	return r.handleValue(fieldVal)
+}
+
+func (r *redactor) conditionHolds(field fieldPlan, parent reflect.Value) bool {
+	if field.spec.condition == "" {
+		return true
+	}
+
+	if field.condErr != nil {
+		r.fail(field.condErr)
+		return true
+	}
+
+	holds, err := field.cond.eval(parent)
+	if err != nil {
+		r.fail(err)
+		return true
+	}
+
+	return holds
+}
+
+func (r *redactor) redactField(obj reflect.Value, spec tagSpec) reflect.Value {
+	if spec.scope == scopeEach {
+		return r.redactEach(obj, spec)
+	}
+
+	if spec.encoding != "" {
+		return r.redactEncoded(obj, spec)
+	}
+
+	if spec.scope != scopeWhole && obj.Kind() == reflect.Map {
+		if spec.scope != scopeEntries || obj.Type().Key().Kind() == reflect.String {
+			return r.handleTaggedMap(obj, spec)
 		}
 	}
 
+	if r.inPlace && obj.Kind() == reflect.Pointer && !obj.IsNil() {
+		return r.redactTarget(obj, spec.strategy)
+	}
+
+	return r.redactValue(obj, spec.strategy)
+}
+
+// redactTarget redacts a pointer in place by overwriting what it points to,
+// so no copy of the sensitive value stays reachable through other pointers.
+func (r *redactor) redactTarget(obj reflect.Value, strategy string) reflect.Value {
+	redacted := r.redactValue(obj, strategy)
+	if redacted.IsNil() {
+		obj.Elem().Set(reflect.Zero(obj.Type().Elem()))
+		return redacted
+	}
+
+	obj.Elem().Set(redacted.Elem())
 	return obj
 }
+
+func (r *redactor) redactEach(obj reflect.Value, spec tagSpec) reflect.Value {
+	switch obj.Kind() {
+	case reflect.Slice:
+		if obj.IsNil() {
+			return obj
+		}
+		redacted := obj
+		if !r.inPlace {
+			redacted = reflect.MakeSlice(obj.Type(), obj.Len(), obj.Len())
+		}
+		for i := 0; i < obj.Len(); i++ {
+			r.enterIndex(i)
+			redacted.Index(i).Set(r.redactEach(obj.Index(i), spec))
+			r.leave()
+		}
+		return redacted
+	case reflect.Array:
+		redacted := obj
+		if !r.owns(obj) {
+			redacted = reflect.New(obj.Type()).Elem()
+		}
+		for i := 0; i < obj.Len(); i++ {
+			r.enterIndex(i)
+			redacted.Index(i).Set(r.redactEach(obj.Index(i), spec))
+			r.leave()
+		}
+		return redacted
+	case reflect.Pointer:
+		if obj.IsNil() {
+			return obj
+		}
+		redacted := obj
+		if !r.inPlace {
+			redacted = reflect.New(obj.Type().Elem())
+		}
+		redacted.Elem().Set(r.redactEach(obj.Elem(), spec))
+		return redacted
+	}
+
+	if spec.encoding != "" {
+		return r.redactEncoded(obj, spec)
+	}
+
+	return r.redactValue(obj, spec.strategy)
+}
+
+func redactedValue(valType reflect.Type, tag string) reflect.Value {
+	meta, exists := customRedacts[valType]
+	if !exists {
+		return reflect.Zero(valType)
+	}
+
+	if specificValue, exists := meta.specificVals[tag]; exists {
+		return specificValue
+	}
+
+	if meta.defaultVal != (reflect.Value{}) {
+		return meta.defaultVal
+	}
+
+	return reflect.Zero(valType)
+}
@@ -698,11 +698,12 @@ func TestRedact(t *testing.T) {
 		},
 	}
 	expectedTestObjInt := TestObjInt{
-		A: nil,
+		A: &StructFieldInlineRedact{},
 	}
 
 	redactedtestObjInt := Redact(testObjInt)
 	require.Equal(t, expectedTestObjInt, redactedtestObjInt)
+	require.Equal(t, "123", testObjInt.A.(*StructFieldInlineRedact).F1)
 
 	var invalidObj interface{}
 	Redact(invalidObj)
@@ -720,6 +721,29 @@ func TestRedact(t *testing.T) {
 	require.Equal(t, expectedTestObjUnex, redactedTestObjUnex)
 }
 
+func TestRedactPlainContainers(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type PlainStruct struct {
+		Map   map[string]string
+		Arr   [2]string
+		Slice []*string
+	}
+
+	obj := PlainStruct{
+		Map:   map[string]string{"k": "v"},
+		Arr:   [2]string{"a", "b"},
+		Slice: []*string{vToP("c")},
+	}
+
+	redacted, stats, err := TryRedact(obj)
+	require.Nil(t, err)
+	require.Equal(t, obj, redacted)
+	require.Equal(t, Stats{}, stats)
+
+	require.Equal(t, [0]string{}, Redact([0]string{}))
+}
+
 func vToP[T any](v T) *T {
 	return &v
 }
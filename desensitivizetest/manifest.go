@@ -0,0 +1,80 @@
+// Package desensitivizetest provides helpers for testing the handling of
+// sensitive data.
+package desensitivizetest
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xxbtwxx/desensitivize"
+)
+
+var update = flag.Bool("desensitivize.update", false, "regenerate desensitivize manifests")
+
+// AssertManifest fails the test when the manifest of T differs from the one
+// stored at path. Running the tests with -desensitivize.update or with
+// DESENSITIVIZE_UPDATE=1 in the environment writes the current manifest
+// instead.
+func AssertManifest[T any](t testing.TB, path string) {
+	t.Helper()
+
+	manifest := desensitivize.Manifest[T]()
+
+	if *update || os.Getenv("DESENSITIVIZE_UPDATE") == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("desensitivizetest: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+			t.Fatalf("desensitivizetest: %v", err)
+		}
+		return
+	}
+
+	stored, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("desensitivizetest: manifest %s doesn't exist, run the tests with -desensitivize.update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("desensitivizetest: %v", err)
+	}
+
+	if string(stored) == manifest {
+		return
+	}
+
+	t.Errorf("desensitivizetest: manifest %s is outdated, run the tests with -desensitivize.update after reviewing the changes:\n%s",
+		path, diffLines(string(stored), manifest))
+}
+
+func diffLines(stored, current string) string {
+	storedLines := lineSet(stored)
+	currentLines := lineSet(current)
+
+	var diff strings.Builder
+	for _, line := range strings.Split(stored, "\n") {
+		if line != "" && !currentLines[line] {
+			diff.WriteString("- " + line + "\n")
+		}
+	}
+	for _, line := range strings.Split(current, "\n") {
+		if line != "" && !storedLines[line] {
+			diff.WriteString("+ " + line + "\n")
+		}
+	}
+
+	return diff.String()
+}
+
+func lineSet(text string) map[string]bool {
+	lines := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		lines[line] = true
+	}
+
+	return lines
+}
@@ -0,0 +1,89 @@
+package desensitivizetest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	Address struct {
+		Street string `sensitive:"-"`
+		City   string
+	}
+
+	User struct {
+		Name     string
+		Password string `sensitive:"mask"`
+		Address  *Address
+		Tokens   []string          `sensitive:"each,mask"`
+		Headers  map[string]string `sensitive:"entries=authorization"`
+		TaxID    string            `sensitive:"if=Country in DE,FR"`
+		Country  string
+		Extra    any
+		Age      int
+	}
+)
+
+func TestAssertManifest(t *testing.T) {
+	AssertManifest[User](t, "testdata/user.manifest")
+}
+
+func TestAssertManifestChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.manifest")
+
+	stored := strings.Replace(readFile(t, "testdata/user.manifest"), "Password string redact \"mask\"\n", "", 1)
+	require.Nil(t, os.WriteFile(path, []byte(stored), 0o644))
+
+	recorder := &recordingTB{TB: t}
+	AssertManifest[User](recorder, path)
+	require.True(t, recorder.failed)
+	require.Contains(t, recorder.message, "+ Password string redact \"mask\"\n")
+
+	recorder = &recordingTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		AssertManifest[User](recorder, filepath.Join(t.TempDir(), "missing.manifest"))
+	}()
+	<-done
+	require.True(t, recorder.failed)
+	require.Contains(t, recorder.message, "doesn't exist")
+}
+
+func TestAssertManifestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new", "user.manifest")
+
+	t.Setenv("DESENSITIVIZE_UPDATE", "1")
+	AssertManifest[User](t, path)
+
+	require.Equal(t, readFile(t, "testdata/user.manifest"), readFile(t, path))
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	require.Nil(t, err)
+	return string(content)
+}
+
+type recordingTB struct {
+	testing.TB
+	failed  bool
+	message string
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failed = true
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.failed = true
+	r.message = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
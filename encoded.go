@@ -0,0 +1,83 @@
+package desensitivize
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+)
+
+// Encodings of documents embedded in strings and byte slices.
+const (
+	encodingJSON       = "json"
+	encodingBase64JSON = "base64json"
+)
+
+// base64Encodings are tried in order, and the first one decoding the value
+// encodes it again.
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.URLEncoding,
+	base64.RawStdEncoding,
+	base64.RawURLEncoding,
+}
+
+// redactEncoded redacts the values selected by the rules of spec inside the
+// document obj holds. When obj doesn't hold a valid document it's redacted as
+// a whole instead.
+func (r *redactor) redactEncoded(obj reflect.Value, spec tagSpec) reflect.Value {
+	if err := checkRules(spec.rules); err != nil {
+		r.fail(err)
+		return r.redactValue(obj, spec.strategy)
+	}
+
+	var data []byte
+	switch {
+	case obj.Kind() == reflect.String:
+		data = []byte(obj.String())
+	case obj.Kind() == reflect.Slice && obj.Type().Elem().Kind() == reflect.Uint8:
+		if obj.IsNil() {
+			return obj
+		}
+		data = obj.Bytes()
+	default:
+		r.fail(fmt.Errorf("desensitivize: %s option on %s, want a string or []byte", spec.encoding, obj.Type()))
+		return reflect.Zero(obj.Type())
+	}
+
+	redacted, ok := r.redactDocument(data, spec)
+	if !ok {
+		return r.redactValue(obj, spec.strategy)
+	}
+
+	if obj.Kind() == reflect.String {
+		return reflect.ValueOf(string(redacted)).Convert(obj.Type())
+	}
+
+	return reflect.ValueOf(redacted).Convert(obj.Type())
+}
+
+func (r *redactor) redactDocument(data []byte, spec tagSpec) ([]byte, bool) {
+	if spec.encoding == encodingJSON {
+		redacted, err := r.redactJSON(data, spec.rules)
+		return redacted, err == nil
+	}
+
+	for _, encoding := range base64Encodings {
+		decoded := make([]byte, encoding.DecodedLen(len(data)))
+		n, err := encoding.Decode(decoded, data)
+		if err != nil {
+			continue
+		}
+
+		redacted, err := r.redactJSON(decoded[:n], spec.rules)
+		if err != nil {
+			return nil, false
+		}
+
+		encoded := make([]byte, encoding.EncodedLen(len(redacted)))
+		encoding.Encode(encoded, redacted)
+		return encoded, true
+	}
+
+	return nil, false
+}
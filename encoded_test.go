@@ -0,0 +1,132 @@
+package desensitivize
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type encodedPayload struct {
+	Kind     string
+	RawBody  []byte   `sensitive:"json:$.password,$.card.number"`
+	Metadata string   `sensitive:"mask:last=2,json:$..token"`
+	Encoded  string   `sensitive:"base64json:$.secret"`
+	Pages    []string `sensitive:"each,json:$[*].pin"`
+	Optional string   `sensitive:"json:$.password,if=Kind == login"`
+}
+
+func TestRedactEncoded(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	newPayload := func() encodedPayload {
+		return encodedPayload{
+			Kind:     "login",
+			RawBody:  []byte(`{"user": "john", "password": "hunter2", "card": {"number": 4111}}`),
+			Metadata: `{"auth": {"token": "abcdef"}, "tags": ["a"]}`,
+			Encoded:  base64.URLEncoding.EncodeToString([]byte(`{"secret":"s3cr3t>?","id":1}`)),
+			Pages:    []string{`[{"pin":"1234"}]`, "not json"},
+			Optional: `{"password":"x"}`,
+		}
+	}
+
+	expected := encodedPayload{
+		Kind:     "login",
+		RawBody:  []byte(`{"user":"john","password":"","card":{"number":null}}`),
+		Metadata: `{"auth":{"token":"****ef"},"tags":["a"]}`,
+		Encoded:  base64.URLEncoding.EncodeToString([]byte(`{"secret":"","id":1}`)),
+		Pages:    []string{`[{"pin":""}]`, ""},
+		Optional: `{"password":""}`,
+	}
+
+	for _, mode := range []CopyMode{CopyDeep, CopyOnWrite} {
+		SetCopyMode(mode)
+
+		payload := newPayload()
+		redacted, _, err := TryRedact(payload)
+		require.NoError(t, err)
+		require.Equal(t, expected, redacted)
+		require.Equal(t, newPayload(), payload)
+	}
+	SetCopyMode(CopyDeep)
+
+	payload := newPayload()
+	require.NoError(t, RedactInPlace(&payload))
+	require.Equal(t, expected, payload)
+
+	payload = newPayload()
+	payload.Kind = "other"
+	require.Equal(t, `{"password":"x"}`, Redact(payload).Optional)
+}
+
+func TestRedactEncodedFailsSafe(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	redacted, _, err := TryRedact(encodedPayload{
+		RawBody:  []byte(`{"password": "hunter2"`),
+		Metadata: `{"token": "abcdef"} trailing`,
+		Encoded:  "not base64!",
+	})
+	require.NoError(t, err)
+	require.Equal(t, encodedPayload{
+		RawBody:  nil,
+		Metadata: "**************************ng",
+		Encoded:  "",
+	}, redacted)
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"secret": `))
+	require.Equal(t, "", Redact(encodedPayload{Encoded: encoded}).Encoded)
+}
+
+func TestRedactEncodedErrors(t *testing.T) {
+	_, _, err := TryRedact(struct {
+		Body string `sensitive:"json:user"`
+	}{Body: `{"user":"x"}`})
+	require.EqualError(t, err, `desensitivize: selector "user" doesn't start with $`)
+
+	_, _, err = TryRedact(struct {
+		Body int `sensitive:"json:$.user"`
+	}{Body: 1})
+	require.EqualError(t, err, "desensitivize: json option on int, want a string or []byte")
+}
+
+func TestRedactEncodedEvents(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	var events []Event
+	redactHooks = append(redactHooks, func(event Event) {
+		events = append(events, event)
+	})
+	defer func() { redactHooks = nil }()
+
+	Redact(struct {
+		Body string `sensitive:"json:$.user.password"`
+	}{Body: `{"user": {"password": "x"}}`})
+	require.Equal(t, []Event{
+		{Path: "Body.user.password", Tag: "json:$.user.password", Type: "string"},
+	}, events)
+}
+
+func TestParseEncodedTag(t *testing.T) {
+	spec := parseTag("mask,json:$.a,$['b,c'],if=Kind == x")
+	require.Equal(t, "mask", spec.strategy)
+	require.Equal(t, encodingJSON, spec.encoding)
+	require.Equal(t, []string{"$.a", "$['b", "c']"}, spec.selectors())
+	require.Equal(t, "Kind == x", spec.condition)
+
+	spec = parseTag("base64json:$..token,keys")
+	require.Equal(t, encodingBase64JSON, spec.encoding)
+	require.Equal(t, scopeWhole, spec.scope)
+	require.Equal(t, []string{"$..token", "keys"}, spec.selectors())
+}
+
+func TestExplainEncoded(t *testing.T) {
+	require.Equal(t, `desensitivize.encodedPayload
+  RawBody []uint8: redact in json $.password,$.card.number
+  Metadata string: redact in json $..token using "mask:last=2"
+  Encoded string: redact in base64json $.secret
+  Pages []string: each in json $[*].pin
+  Optional string: redact in json $.password if Kind == login
+`, Explain[encodedPayload]().String())
+}
@@ -0,0 +1,265 @@
+package desensitivize
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	ActionRedact  = "redact"
+	ActionEach    = "each"
+	ActionKeys    = "keys"
+	ActionValues  = "values"
+	ActionEntries = "entries"
+	// ActionBuiltin marks types with their own handling, such as url.URL.
+	ActionBuiltin = "builtin"
+	// ActionZero marks unexported fields leading to tags, which are zeroed
+	// since they can't be walked.
+	ActionZero = "zero"
+	// ActionKeyRules marks maps with string keys the rules set by SetKeyRules
+	// apply to. Entries lists their patterns.
+	ActionKeyRules = "key-rules"
+)
+
+// Plan describes how values of a type would be redacted.
+type Plan struct {
+	Root *PlanNode `json:"root"`
+}
+
+// PlanNode is a field, element, key or value on the way to redacted data.
+// Paths use [*] for any index or map value and {key} for map keys.
+type PlanNode struct {
+	Name      string      `json:"name,omitempty"`
+	Path      string      `json:"path,omitempty"`
+	Type      string      `json:"type"`
+	Action    string      `json:"action,omitempty"`
+	Tag       string      `json:"tag,omitempty"`
+	Strategy  string      `json:"strategy,omitempty"`
+	Entries   []string    `json:"entries,omitempty"`
+	Encoding  string      `json:"encoding,omitempty"`
+	Selectors []string    `json:"selectors,omitempty"`
+	Condition string      `json:"condition,omitempty"`
+	Recursive bool        `json:"recursive,omitempty"`
+	Children  []*PlanNode `json:"children,omitempty"`
+}
+
+// Explain analyzes T without a value and returns the redaction plan for it,
+// including only the parts of T which lead to redacted data.
+func Explain[T any]() Plan {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	root := explainType(typ, "", "", map[reflect.Type]bool{})
+	if root == nil {
+		root = &PlanNode{Type: typ.String()}
+	}
+
+	return Plan{Root: root}
+}
+
+// Empty reports whether the plan redacts nothing.
+func (p Plan) Empty() bool {
+	return p.Root == nil || (p.Root.Action == "" && len(p.Root.Children) == 0)
+}
+
+func (p Plan) String() string {
+	var text strings.Builder
+	if p.Root != nil {
+		writePlanNode(&text, p.Root, 0)
+	}
+
+	return text.String()
+}
+
+func explainType(typ reflect.Type, name, path string, visiting map[reflect.Type]bool) *PlanNode {
+	node := &PlanNode{
+		Name: name,
+		Path: path,
+		Type: typ.String(),
+	}
+
+	elemType := typ
+	for elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+
+	if _, handled := typeHandlers[elemType]; handled {
+		node.Action = ActionBuiltin
+		return node
+	}
+
+	if visiting[elemType] {
+		if !planFor(elemType).sensitive {
+			return nil
+		}
+
+		node.Recursive = true
+		return node
+	}
+
+	switch elemType.Kind() {
+	case reflect.Struct:
+		visiting[elemType] = true
+		for i := 0; i < elemType.NumField(); i++ {
+			if !elemType.Field(i).IsExported() {
+				if zeroed(elemType, i) {
+					node.Children = append(node.Children, zeroedNode(elemType.Field(i), path))
+				}
+				continue
+			}
+
+			if child := explainField(elemType, i, path, visiting); child != nil {
+				node.Children = append(node.Children, child)
+			}
+		}
+		delete(visiting, elemType)
+	case reflect.Slice, reflect.Array:
+		if child := explainType(elemType.Elem(), "[*]", path+"[*]", visiting); child != nil {
+			node.Children = append(node.Children, child)
+		}
+	case reflect.Map:
+		if keyRulesApply(elemType) {
+			node.Action = ActionKeyRules
+			node.Entries = keyRulePatterns()
+		}
+
+		switch elemType.Key().Kind() {
+		case reflect.Struct, reflect.Array, reflect.Pointer:
+			if child := explainType(elemType.Key(), "{key}", path+"{key}", visiting); child != nil {
+				node.Children = append(node.Children, child)
+			}
+		}
+
+		if child := explainType(elemType.Elem(), "[*]", path+"[*]", visiting); child != nil {
+			node.Children = append(node.Children, child)
+		}
+	}
+
+	if len(node.Children) == 0 && node.Action == "" {
+		return nil
+	}
+
+	return node
+}
+
+func explainField(structType reflect.Type, index int, parentPath string, visiting map[reflect.Type]bool) *PlanNode {
+	field := structType.Field(index)
+
+	path := field.Name
+	if parentPath != "" {
+		path = parentPath + "." + field.Name
+	}
+
+	tag, exist := lookupTag(structType, index)
+	if !exist {
+		return explainType(field.Type, field.Name, path, visiting)
+	}
+
+	spec := parseTag(tag)
+	node := &PlanNode{
+		Name:      field.Name,
+		Path:      path,
+		Type:      field.Type.String(),
+		Action:    fieldAction(field.Type, spec),
+		Tag:       tag,
+		Strategy:  spec.strategy,
+		Condition: spec.condition,
+	}
+
+	if node.Action == ActionEntries {
+		node.Entries = spec.entries
+	}
+
+	if spec.encoding != "" {
+		node.Encoding = spec.encoding
+		node.Selectors = spec.selectors()
+	}
+
+	switch {
+	case spec.condition != "":
+		if walked := explainType(field.Type, field.Name, path, visiting); walked != nil {
+			node.Children = walked.Children
+		}
+	case node.Action == ActionKeys || node.Action == ActionEntries:
+		if child := explainType(field.Type.Elem(), "[*]", path+"[*]", visiting); child != nil {
+			node.Children = []*PlanNode{child}
+		}
+	}
+
+	return node
+}
+
+// zeroed tells whether the unexported field index of structType is zeroed.
+func zeroed(structType reflect.Type, index int) bool {
+	if _, tagged := lookupTag(structType, index); tagged {
+		return true
+	}
+
+	return reachesTag(structType.Field(index).Type, map[reflect.Type]bool{})
+}
+
+func zeroedNode(field reflect.StructField, parentPath string) *PlanNode {
+	return &PlanNode{
+		Name:   field.Name,
+		Path:   fieldPath(parentPath, field.Name),
+		Type:   field.Type.String(),
+		Action: ActionZero,
+	}
+}
+
+func fieldAction(fieldType reflect.Type, spec tagSpec) string {
+	switch spec.scope {
+	case scopeEach:
+		return ActionEach
+	case scopeKeys, scopeValues, scopeEntries:
+		if fieldType.Kind() != reflect.Map {
+			break
+		}
+
+		switch spec.scope {
+		case scopeKeys:
+			return ActionKeys
+		case scopeValues:
+			return ActionValues
+		case scopeEntries:
+			if fieldType.Key().Kind() == reflect.String {
+				return ActionEntries
+			}
+		}
+	}
+
+	return ActionRedact
+}
+
+func writePlanNode(text *strings.Builder, node *PlanNode, depth int) {
+	text.WriteString(strings.Repeat("  ", depth))
+	if node.Name != "" {
+		text.WriteString(node.Name)
+		text.WriteByte(' ')
+	}
+	text.WriteString(node.Type)
+
+	if node.Action != "" {
+		fmt.Fprintf(text, ": %s", node.Action)
+		if len(node.Entries) > 0 {
+			fmt.Fprintf(text, " %s", strings.Join(node.Entries, "|"))
+		}
+		if node.Encoding != "" {
+			fmt.Fprintf(text, " in %s %s", node.Encoding, strings.Join(node.Selectors, ","))
+		}
+		if node.Strategy != "" {
+			fmt.Fprintf(text, " using %q", node.Strategy)
+		}
+		if node.Condition != "" {
+			fmt.Fprintf(text, " if %s", node.Condition)
+		}
+	}
+	if node.Recursive {
+		text.WriteString(" (recursive)")
+	}
+	text.WriteByte('\n')
+
+	for _, child := range node.Children {
+		writePlanNode(text, child, depth+1)
+	}
+}
@@ -0,0 +1,83 @@
+package desensitivize
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	fieldPolicies = map[reflect.Type]map[int]string{}
+
+	type (
+		Address struct {
+			Street string `sensitive:"-"`
+			City   string
+		}
+
+		MapKey struct {
+			ID string `sensitive:"-"`
+		}
+
+		Node struct {
+			Secret   string `sensitive:"mask"`
+			Children []Node
+		}
+
+		User struct {
+			Name    string
+			Address *Address
+			Tokens  []string `sensitive:"each,mask"`
+			TaxID   string   `sensitive:"if=Country in DE,FR"`
+			Country string
+		}
+
+		Response struct {
+			Users   []User
+			Meta    map[string]Address `sensitive:"entries=token|secret"`
+			Keys    map[MapKey]int
+			Tree    Node
+			Plain   []string
+			private Address
+			err     error
+		}
+	)
+
+	plan := Explain[Response]()
+	require.False(t, plan.Empty())
+	require.Equal(t, `desensitivize.Response
+  Users []desensitivize.User
+    [*] desensitivize.User
+      Address *desensitivize.Address
+        Street string: redact using "-"
+      Tokens []string: each using "mask"
+      TaxID string: redact if Country in DE,FR
+  Meta map[string]desensitivize.Address: entries token|secret
+    [*] desensitivize.Address
+      Street string: redact using "-"
+  Keys map[desensitivize.MapKey]int
+    {key} desensitivize.MapKey
+      ID string: redact using "-"
+  Tree desensitivize.Node
+    Secret string: redact using "mask"
+    Children []desensitivize.Node
+      [*] desensitivize.Node (recursive)
+  private desensitivize.Address: zero
+`, plan.String())
+
+	encoded, err := json.Marshal(Explain[Address]())
+	require.Nil(t, err)
+	require.JSONEq(t, `{"root": {
+		"type": "desensitivize.Address",
+		"children": [
+			{"name": "Street", "path": "Street", "type": "string", "action": "redact", "tag": "-", "strategy": "-"}
+		]
+	}}`, string(encoded))
+
+	require.Equal(t, "Users[*].Address.Street", plan.Root.Children[0].Children[0].Children[0].Children[0].Path)
+
+	require.True(t, Explain[map[string][]int]().Empty())
+	require.Equal(t, "map[string][]int\n", Explain[map[string][]int]().String())
+}
@@ -0,0 +1,37 @@
+package desensitivize
+
+import "expvar"
+
+// Event describes a redacted value passed to OnRedact hooks.
+type Event = Redaction
+
+// OnRedact registers fn to be called for every redacted value by all
+// redaction functions. Hooks run synchronously in registration order.
+func OnRedact(fn func(Event)) {
+	redactHooks = append(redactHooks, fn)
+}
+
+// PublishExpvar publishes redaction counters under name and keeps them up to
+// date with an OnRedact hook. The map holds the "total" count and the
+// "tags", "strategies" and "types" maps counting redactions per value.
+// Like expvar.NewMap it panics when name is already published.
+func PublishExpvar(name string) *expvar.Map {
+	counters := expvar.NewMap(name)
+
+	tags := new(expvar.Map).Init()
+	strategies := new(expvar.Map).Init()
+	types := new(expvar.Map).Init()
+
+	counters.Set("tags", tags)
+	counters.Set("strategies", strategies)
+	counters.Set("types", types)
+
+	OnRedact(func(event Event) {
+		counters.Add("total", 1)
+		tags.Add(event.Tag, 1)
+		strategies.Add(event.Strategy, 1)
+		types.Add(event.Type, 1)
+	})
+
+	return counters
+}
@@ -0,0 +1,50 @@
+package desensitivize
+
+import (
+	"expvar"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnRedact(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	redactHooks = nil
+	defer func() {
+		redactHooks = nil
+	}()
+
+	type RedactStruct struct {
+		Email  string   `sensitive:"lower"`
+		Tokens []string `sensitive:"each,mask"`
+		Plain  string
+	}
+
+	var events []Event
+	OnRedact(func(event Event) {
+		events = append(events, event)
+	})
+	counters := PublishExpvar("desensitivize_test_redactions")
+
+	obj := RedactStruct{
+		Email:  "EMAIL",
+		Tokens: []string{"a", "b"},
+		Plain:  "plain",
+	}
+
+	Redact(obj)
+	Redact(obj)
+
+	require.Equal(t, []Event{
+		{Path: "Email", Tag: "lower", Strategy: "lower", Type: "string"},
+		{Path: "Tokens[0]", Tag: "each,mask", Strategy: "mask", Type: "string"},
+		{Path: "Tokens[1]", Tag: "each,mask", Strategy: "mask", Type: "string"},
+	}, events[:3])
+	require.Len(t, events, 6)
+
+	require.Equal(t, "6", counters.Get("total").String())
+	require.Equal(t, "6", counters.Get("types").(*expvar.Map).Get("string").String())
+	require.Equal(t, "2", counters.Get("strategies").(*expvar.Map).Get("lower").String())
+	require.Equal(t, "4", counters.Get("tags").(*expvar.Map).Get("each,mask").String())
+}
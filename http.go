@@ -0,0 +1,278 @@
+package desensitivize
+
+import (
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const headerStrategy = "header"
+
+var (
+	headerType   = reflect.TypeOf(http.Header{})
+	cookieType   = reflect.TypeOf(http.Cookie{})
+	requestType  = reflect.TypeOf(http.Request{})
+	responseType = reflect.TypeOf(http.Response{})
+	formType     = reflect.TypeOf(multipart.Form{})
+
+	sensitiveHeaders []*regexp.Regexp
+	sensitiveCookies []*regexp.Regexp
+)
+
+func init() {
+	SetSensitiveHeaders("Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Proxy-Authorization")
+	SetSensitiveCookies("*")
+
+	typeHandlers[headerType] = (*redactor).handleHeader
+	typeHandlers[cookieType] = (*redactor).handleCookie
+
+	handleStructFields(requestType, "URL", "Header", "Trailer", "Form", "PostForm", "MultipartForm", "Response")
+	handleStructFields(responseType, "Header", "Trailer", "Request")
+
+	// RequestURI is a string, so it's redacted besides the fields.
+	typeHandlers[requestType] = (*redactor).handleRequest
+
+	handledFields[formType] = []string{"Value"}
+	typeHandlers[formType] = (*redactor).handleMultipartForm
+}
+
+// handleStructFields makes Redact walk only the given fields of typ. The
+// others, such as bodies and connections, are left as they are.
+func handleStructFields(typ reflect.Type, fields ...string) {
+	handledFields[typ] = fields
+	typeHandlers[typ] = func(r *redactor, obj reflect.Value) (reflect.Value, bool) {
+		return r.handleFields(obj, fields)
+	}
+}
+
+// SetSensitiveHeaders replaces the names of the HTTP headers whose values are
+// redacted. Names are case-insensitive and may contain * and ? wildcards like
+// key rules. The values of Cookie and Set-Cookie headers are redacted per
+// cookie.
+func SetSensitiveHeaders(names ...string) {
+	sensitiveHeaders = make([]*regexp.Regexp, len(names))
+	for i, name := range names {
+		sensitiveHeaders[i] = globPattern(name)
+	}
+}
+
+// AddSensitiveHeaders extends the names of the HTTP headers whose values are
+// redacted.
+func AddSensitiveHeaders(names ...string) {
+	for _, name := range names {
+		sensitiveHeaders = append(sensitiveHeaders, globPattern(name))
+	}
+}
+
+// SetSensitiveCookies replaces the names of the cookies whose values are
+// redacted in Cookie and Set-Cookie headers and http.Cookie values. By
+// default all of them are.
+func SetSensitiveCookies(names ...string) {
+	sensitiveCookies = make([]*regexp.Regexp, len(names))
+	for i, name := range names {
+		sensitiveCookies[i] = globPattern(name)
+	}
+}
+
+func matchesAny(patterns []*regexp.Regexp, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *redactor) handleHeader(obj reflect.Value) (reflect.Value, bool) {
+	header := obj.Interface().(http.Header)
+	redacted := header
+	changed := false
+
+	for name, values := range header {
+		if !matchesAny(sensitiveHeaders, name) {
+			continue
+		}
+
+		replaced, valuesChanged := redactHeaderValues(name, values)
+		if !valuesChanged {
+			continue
+		}
+
+		if !changed && !r.inPlace {
+			redacted = make(http.Header, len(header))
+			for name, values := range header {
+				redacted[name] = values
+			}
+		}
+		changed = true
+
+		r.enterKey(reflect.ValueOf(name))
+		r.record(reflect.TypeOf(values), headerStrategy)
+		r.leave()
+
+		if r.inPlace {
+			copy(values, replaced)
+		} else {
+			redacted[name] = replaced
+		}
+	}
+
+	return reflect.ValueOf(redacted), changed
+}
+
+func redactHeaderValues(name string, values []string) ([]string, bool) {
+	replaced := make([]string, len(values))
+	changed := false
+	name = http.CanonicalHeaderKey(name)
+
+	for i, value := range values {
+		switch name {
+		case "Cookie":
+			replaced[i] = redactCookieHeader(value)
+		case "Set-Cookie":
+			replaced[i] = redactSetCookieHeader(value)
+		case "Authorization", "Proxy-Authorization":
+			// The scheme tells how the request was authenticated.
+			if scheme, _, found := strings.Cut(value, " "); found {
+				replaced[i] = scheme + " " + redactedURLValue
+			} else {
+				replaced[i] = redactedURLValue
+			}
+		default:
+			replaced[i] = redactedURLValue
+		}
+
+		changed = changed || replaced[i] != value
+	}
+
+	return replaced, changed
+}
+
+// redactCookieHeader redacts the values of sensitive cookies in a Cookie
+// header like session=abc; theme=dark.
+func redactCookieHeader(value string) string {
+	cookies := strings.Split(value, ";")
+	for i, cookie := range cookies {
+		name, _, found := strings.Cut(cookie, "=")
+		if found && matchesAny(sensitiveCookies, strings.TrimSpace(name)) {
+			cookies[i] = name + "=" + redactedURLValue
+		}
+	}
+
+	return strings.Join(cookies, ";")
+}
+
+// redactSetCookieHeader redacts the value of a sensitive cookie in a
+// Set-Cookie header like session=abc; Path=/; HttpOnly.
+func redactSetCookieHeader(value string) string {
+	cookie, attributes, hasAttributes := strings.Cut(value, ";")
+	name, _, found := strings.Cut(cookie, "=")
+	if !found || !matchesAny(sensitiveCookies, strings.TrimSpace(name)) {
+		return value
+	}
+
+	if !hasAttributes {
+		return name + "=" + redactedURLValue
+	}
+
+	return name + "=" + redactedURLValue + ";" + attributes
+}
+
+func (r *redactor) handleCookie(obj reflect.Value) (reflect.Value, bool) {
+	cookie := obj.Interface().(http.Cookie)
+	if cookie.Value == "" || !matchesAny(sensitiveCookies, cookie.Name) {
+		return obj, false
+	}
+
+	cookie.Value = redactedURLValue
+	cookie.Raw = ""
+
+	r.enterField("Value")
+	r.record(reflect.TypeOf(cookie.Value), headerStrategy)
+	r.leave()
+
+	return reflect.ValueOf(cookie), true
+}
+
+func (r *redactor) handleRequest(obj reflect.Value) (reflect.Value, bool) {
+	redacted, changed := r.handleFields(obj, handledFields[requestType])
+
+	uri := obj.FieldByName("RequestURI").String()
+	if uri == "" {
+		return redacted, changed
+	}
+
+	redactedURI, err := redactURLString(uri)
+	if err != nil {
+		redactedURI = redactedURLValue
+	}
+
+	if redactedURI == uri {
+		return redacted, changed
+	}
+
+	if !changed && !r.owns(obj) {
+		redacted = reflect.New(obj.Type()).Elem()
+		redacted.Set(obj)
+	}
+
+	r.enterField("RequestURI")
+	r.record(reflect.TypeOf(uri), urlStrategy)
+	r.leave()
+
+	redacted.FieldByName("RequestURI").SetString(redactedURI)
+	return redacted, true
+}
+
+// handleMultipartForm redacts the sensitive parameters of a parsed multipart
+// form like url.Values. Files are left as they are.
+func (r *redactor) handleMultipartForm(obj reflect.Value) (reflect.Value, bool) {
+	values := obj.FieldByName("Value")
+
+	r.enterField("Value")
+	redactedValues, changed := r.handleURLValues(values.Convert(urlValuesType))
+	r.leave()
+
+	if !changed {
+		return obj, false
+	}
+
+	redacted := obj
+	if !r.owns(obj) {
+		redacted = reflect.New(obj.Type()).Elem()
+		redacted.Set(obj)
+	}
+
+	redacted.FieldByName("Value").Set(redactedValues.Convert(values.Type()))
+	return redacted, true
+}
+
+// handleFields redacts the given fields of a struct, leaving the others
+// alone.
+func (r *redactor) handleFields(obj reflect.Value, fields []string) (reflect.Value, bool) {
+	redacted := obj
+	changed := false
+
+	for _, name := range fields {
+		r.enterField(name)
+		field, fieldChanged := r.handleValue(obj.FieldByName(name))
+		r.leave()
+
+		if !fieldChanged {
+			continue
+		}
+
+		if !changed && !r.owns(obj) {
+			redacted = reflect.New(obj.Type()).Elem()
+			redacted.Set(obj)
+		}
+		changed = true
+
+		redacted.FieldByName(name).Set(field)
+	}
+
+	return redacted, changed
+}
@@ -0,0 +1,24 @@
+package desensitivize
+
+import "reflect"
+
+// RedactInPlace redacts the value ptr points to without copying it. This is
+// destructive: the value is modified through the same walkers Redact uses,
+// including map keys and the targets of pointers, so everything sharing
+// memory with it sees the redacted data as well. Use it for values which are
+// discarded after being logged or persisted.
+// It returns the same errors as TryRedact.
+func RedactInPlace[T any](ptr *T) error {
+	if ptr == nil || !planFor(reflect.TypeOf(ptr).Elem()).sensitive {
+		return nil
+	}
+
+	r := &redactor{inPlace: true}
+
+	objValue := reflect.ValueOf(ptr).Elem()
+	if redacted, changed := r.handleValue(objValue); changed {
+		objValue.Set(redacted)
+	}
+
+	return r.err
+}
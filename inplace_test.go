@@ -0,0 +1,98 @@
+package desensitivize
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactInPlace(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type (
+		Secret struct {
+			Token string `sensitive:"-"`
+			Note  string
+		}
+
+		Owned struct {
+			Secrets []Secret
+			ByName  map[string]*Secret
+			Keyed   map[Secret]int
+			Pair    [2]Secret
+			Pointer *string   `sensitive:"-"`
+			Each    []*string `sensitive:"each,-"`
+			Kind    string
+			Key     string `sensitive:"if=Kind==secret"`
+			Kept    string `sensitive:"if=Key==secret"`
+		}
+	)
+
+	token := "token"
+	item := "item"
+	shared := &Secret{Token: "b", Note: "y"}
+
+	obj := Owned{
+		Secrets: []Secret{{Token: "a", Note: "x"}},
+		ByName:  map[string]*Secret{"b": shared},
+		Keyed:   map[Secret]int{{Token: "c", Note: "z"}: 1},
+		Pair:    [2]Secret{{Token: "d"}, {Token: "e"}},
+		Pointer: &token,
+		Each:    []*string{&item},
+		Kind:    "secret",
+		Key:     "secret",
+		Kept:    "kept",
+	}
+	secrets, keyed := obj.Secrets, obj.Keyed
+
+	require.NoError(t, RedactInPlace(&obj))
+
+	require.Equal(t, Owned{
+		Secrets: []Secret{{Note: "x"}},
+		ByName:  map[string]*Secret{"b": {Note: "y"}},
+		Keyed:   map[Secret]int{{Note: "z"}: 1},
+		Kind:    "secret",
+		Each:    []*string{new(string)},
+	}, obj)
+
+	// Everything sharing memory with the value is redacted as well.
+	require.Equal(t, []Secret{{Note: "x"}}, secrets)
+	require.Equal(t, map[Secret]int{{Note: "z"}: 1}, keyed)
+	require.Equal(t, &Secret{Note: "y"}, shared)
+	require.Equal(t, "", token)
+	require.Equal(t, "", item)
+}
+
+func TestRedactInPlaceErrors(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	SetCollisionPolicy(CollisionError)
+	defer SetCollisionPolicy(CollisionOverwrite)
+
+	type Key struct {
+		ID   string `sensitive:"-"`
+		Name string
+	}
+
+	obj := map[Key]int{{ID: "a"}: 1, {ID: "b"}: 2}
+	err := RedactInPlace(&obj)
+	require.True(t, errors.Is(err, ErrKeyCollision))
+	require.Equal(t, map[Key]int{{}: 1}, obj)
+
+	require.NoError(t, RedactInPlace[Key](nil))
+}
+
+func BenchmarkRedactInPlace(b *testing.B) {
+	responses := make([]benchResponse, b.N)
+	for i := range responses {
+		responses[i] = benchResponseValue()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		RedactInPlace(&responses[i])
+	}
+}
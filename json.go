@@ -0,0 +1,703 @@
+package desensitivize
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	jsonFieldCache sync.Map
+)
+
+// MarshalJSON encodes v like json.Marshal would encode Redact(v), honoring
+// json tags. Values are redacted while they are encoded, so nothing but the
+// redacted values themselves is copied. Unlike Redact, which zeroes unexported
+// embedded structs leading to tags as a whole, it encodes their promoted
+// fields and redacts them one by one. Like TryRedact it fails when the
+// redaction does, and like json.Marshal it fails on cycles.
+func MarshalJSON(v any) ([]byte, error) {
+	e := &jsonEncoder{r: &redactor{}}
+	if err := e.run(v); err != nil {
+		return nil, err
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+// JSONEncoder writes redacted JSON values to a stream.
+type JSONEncoder struct {
+	w io.Writer
+}
+
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{w: w}
+}
+
+// Encode writes the redacted JSON encoding of v followed by a newline, like
+// json.Encoder.Encode. Nothing is written when the encoding fails.
+func (enc *JSONEncoder) Encode(v any) error {
+	e := &jsonEncoder{r: &redactor{}}
+	if err := e.run(v); err != nil {
+		return err
+	}
+	e.buf.WriteByte('\n')
+
+	_, err := enc.w.Write(e.buf.Bytes())
+	return err
+}
+
+type jsonEncoder struct {
+	r   *redactor
+	buf bytes.Buffer
+
+	ptrLevel int
+	ptrSeen  map[jsonPointer]bool
+}
+
+type jsonPointer struct {
+	addr uintptr
+	len  int
+}
+
+// startDetectingCyclesAfter is the nesting depth of pointers, maps and slices
+// from which on cycles are detected. Like encoding/json the encoder doesn't
+// track shallower values, keeping the common case cheap.
+const startDetectingCyclesAfter = 1000
+
+// enterPointer fails if v, a non-nil pointer, map or slice, is already being
+// encoded. Each successful call is paired with leavePointer.
+func (e *jsonEncoder) enterPointer(v reflect.Value) error {
+	e.ptrLevel++
+	if e.ptrLevel <= startDetectingCyclesAfter {
+		return nil
+	}
+
+	ptr := jsonPointer{addr: v.Pointer()}
+	if v.Kind() == reflect.Slice {
+		ptr.len = v.Len()
+	}
+
+	if e.ptrSeen[ptr] {
+		e.ptrLevel--
+		return &json.UnsupportedValueError{
+			Value: v,
+			Str:   fmt.Sprintf("encountered a cycle via %s", v.Type()),
+		}
+	}
+
+	if e.ptrSeen == nil {
+		e.ptrSeen = map[jsonPointer]bool{}
+	}
+	e.ptrSeen[ptr] = true
+
+	return nil
+}
+
+func (e *jsonEncoder) leavePointer(v reflect.Value) {
+	if e.ptrLevel > startDetectingCyclesAfter {
+		ptr := jsonPointer{addr: v.Pointer()}
+		if v.Kind() == reflect.Slice {
+			ptr.len = v.Len()
+		}
+		delete(e.ptrSeen, ptr)
+	}
+	e.ptrLevel--
+}
+
+func (e *jsonEncoder) run(v any) error {
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return err
+	}
+
+	return e.r.err
+}
+
+// encode writes v, redacting it on the way. Values encoding/json handles in
+// special ways are left to it.
+func (e *jsonEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf.WriteString("null")
+		return nil
+	}
+
+	plan := planFor(v.Type())
+	sensitive := plan.sensitive
+
+	// Types encoding themselves, or with their own handling, are redacted
+	// first and encoded by encoding/json.
+	if marshals(v) || plan.handler != nil {
+		if sensitive {
+			v, _ = e.r.handleValue(v)
+		}
+		return e.marshal(v)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		writeJSONString(&e.buf, v.String())
+		return nil
+	case reflect.Bool:
+		e.buf.WriteString(strconv.FormatBool(v.Bool()))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.buf.WriteString(strconv.FormatInt(v.Int(), 10))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+
+		if v.Kind() == reflect.Interface {
+			return e.encode(v.Elem())
+		}
+
+		if err := e.enterPointer(v); err != nil {
+			return err
+		}
+		defer e.leavePointer(v)
+
+		return e.encode(v.Elem())
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+
+		// Byte slices are encoded as base64.
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.marshal(v)
+		}
+
+		if v.Kind() == reflect.Slice {
+			if err := e.enterPointer(v); err != nil {
+				return err
+			}
+			defer e.leavePointer(v)
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+
+		if v.Type().Key().Kind() == reflect.String {
+			if err := e.enterPointer(v); err != nil {
+				return err
+			}
+			defer e.leavePointer(v)
+
+			return e.encodeMap(v)
+		}
+
+		// Other keys may need redacting, which can't be done while encoding.
+		if sensitive {
+			v, _ = e.r.handleValue(v)
+		}
+		return e.marshal(v)
+	}
+
+	return e.marshal(v)
+}
+
+func (e *jsonEncoder) encodeStruct(v reflect.Value) error {
+	e.buf.WriteByte('{')
+	first := true
+
+fields:
+	for _, field := range cachedJSONFields(v.Type()) {
+		owner, fieldVal := v, v
+		for i, index := range field.index {
+			if i > 0 {
+				if fieldVal.Kind() == reflect.Pointer {
+					if fieldVal.IsNil() {
+						continue fields
+					}
+					fieldVal = fieldVal.Elem()
+				}
+				owner = fieldVal
+			}
+			fieldVal = fieldVal.Field(index)
+		}
+
+		e.r.enterField(field.goName)
+		fieldVal, redacted := e.redactJSONField(owner, field.index[len(field.index)-1], fieldVal)
+
+		if field.omitEmpty && isEmptyJSONValue(fieldVal) {
+			e.r.leave()
+			continue
+		}
+
+		if !first {
+			e.buf.WriteByte(',')
+		}
+		first = false
+
+		e.buf.WriteString(field.key)
+
+		var err error
+		switch {
+		case field.quoted:
+			err = e.encodeQuoted(fieldVal)
+		case redacted:
+			err = e.encodeRedacted(fieldVal)
+		default:
+			err = e.encode(fieldVal)
+		}
+		e.r.leave()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	e.buf.WriteByte('}')
+	return nil
+}
+
+// redactJSONField redacts the field if it's tagged, returning whether it was.
+func (e *jsonEncoder) redactJSONField(owner reflect.Value, index int, fieldVal reflect.Value) (reflect.Value, bool) {
+	for _, field := range planFor(owner.Type()).fields {
+		if field.index != index || !field.tagged || !e.r.conditionHolds(field, owner) {
+			continue
+		}
+
+		prevTag := e.r.tag
+		e.r.tag = field.tag
+		redacted := e.r.redactField(fieldVal, field.spec)
+		e.r.tag = prevTag
+
+		return redacted, true
+	}
+
+	return fieldVal, false
+}
+
+func (e *jsonEncoder) encodeArray(v reflect.Value) error {
+	e.buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+
+		e.r.enterIndex(i)
+		err := e.encode(v.Index(i))
+		e.r.leave()
+
+		if err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte(']')
+
+	return nil
+}
+
+func (e *jsonEncoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	e.buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+
+		writeJSONString(&e.buf, key.String())
+		e.buf.WriteByte(':')
+
+		e.r.enterKey(key)
+		var err error
+		if rule, matched := matchKey(keyRules, key.String()); matched {
+			err = e.encodeRedacted(e.r.redactDynamic(v.MapIndex(key), rule.strategy))
+		} else {
+			err = e.encode(v.MapIndex(key))
+		}
+		e.r.leave()
+
+		if err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte('}')
+
+	return nil
+}
+
+// encodeRedacted writes a value the redactor returned, which holds nothing
+// left to redact.
+func (e *jsonEncoder) encodeRedacted(v reflect.Value) error {
+	if !planFor(v.Type()).sensitive {
+		return e.encode(v)
+	}
+
+	return e.marshal(v)
+}
+
+// encodeQuoted encodes scalars inside a JSON string like the string option of
+// encoding/json does.
+func (e *jsonEncoder) encodeQuoted(v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		quoted, _ := json.Marshal(string(encoded))
+		e.buf.Write(quoted)
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		e.buf.WriteByte('"')
+		e.buf.Write(encoded)
+		e.buf.WriteByte('"')
+	default:
+		e.buf.Write(encoded)
+	}
+
+	return nil
+}
+
+// marshal encodes a value without anything left to redact with encoding/json.
+func (e *jsonEncoder) marshal(v reflect.Value) error {
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+		v = v.Addr()
+	}
+
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+
+	e.buf.Write(encoded)
+	return nil
+}
+
+func marshals(v reflect.Value) bool {
+	typ := v.Type()
+	if typ.Implements(jsonMarshalerType) || typ.Implements(textMarshalerType) {
+		return true
+	}
+
+	return v.CanAddr() && (reflect.PointerTo(typ).Implements(jsonMarshalerType) || reflect.PointerTo(typ).Implements(textMarshalerType))
+}
+
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+
+	return false
+}
+
+type jsonField struct {
+	name string
+	// key is the quoted name followed by a colon.
+	key       string
+	goName    string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+func cachedJSONFields(typ reflect.Type) []jsonField {
+	if fields, exist := jsonFieldCache.Load(typ); exist {
+		return fields.([]jsonField)
+	}
+
+	fields, _ := jsonFieldCache.LoadOrStore(typ, jsonFields(typ))
+	return fields.([]jsonField)
+}
+
+// jsonFields lists the fields encoding/json encodes for typ, in the same
+// order and following the same rules for embedded structs.
+func jsonFields(typ reflect.Type) []jsonField {
+	type queued struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var (
+		fields  []jsonField
+		current []queued
+		next    = []queued{{typ: typ}}
+		visited = map[reflect.Type]bool{}
+	)
+
+	for len(next) > 0 {
+		current, next = next, nil
+		count := map[string]int{}
+		var level []jsonField
+
+		for _, q := range current {
+			if visited[q.typ] {
+				continue
+			}
+			visited[q.typ] = true
+
+			for i := 0; i < q.typ.NumField(); i++ {
+				sf := q.typ.Field(i)
+
+				fieldType := sf.Type
+				if sf.Anonymous && fieldType.Kind() == reflect.Pointer {
+					fieldType = fieldType.Elem()
+				}
+
+				if !sf.IsExported() && !(sf.Anonymous && fieldType.Kind() == reflect.Struct) {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+
+				name, options, _ := strings.Cut(tag, ",")
+				if !validJSONName(name) {
+					name = ""
+				}
+
+				index := make([]int, len(q.index)+1)
+				copy(index, q.index)
+				index[len(q.index)] = i
+
+				if name == "" && sf.Anonymous && fieldType.Kind() == reflect.Struct {
+					next = append(next, queued{typ: fieldType, index: index})
+					continue
+				}
+
+				if !sf.IsExported() {
+					continue
+				}
+
+				field := jsonField{
+					name:      name,
+					goName:    sf.Name,
+					index:     index,
+					tagged:    name != "",
+					omitEmpty: hasJSONOption(options, "omitempty"),
+				}
+				if field.name == "" {
+					field.name = sf.Name
+				}
+
+				if hasJSONOption(options, "string") {
+					switch fieldType.Kind() {
+					case reflect.Bool, reflect.String,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						field.quoted = true
+					}
+				}
+
+				var key bytes.Buffer
+				writeJSONString(&key, field.name)
+				key.WriteByte(':')
+				field.key = key.String()
+
+				level = append(level, field)
+				count[field.name]++
+			}
+		}
+
+		// Names already taken at a shallower depth dominate, and names
+		// appearing several times at this depth are dropped unless exactly
+		// one of them is tagged.
+		taken := map[string]bool{}
+		for _, field := range fields {
+			taken[field.name] = true
+		}
+
+		for _, field := range level {
+			if taken[field.name] {
+				continue
+			}
+
+			if count[field.name] > 1 {
+				dominant, ok := dominantJSONField(level, field.name)
+				if !ok || dominant.goName != field.goName || !equalIndex(dominant.index, field.index) {
+					continue
+				}
+			}
+
+			fields = append(fields, field)
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+
+	return fields
+}
+
+func dominantJSONField(level []jsonField, name string) (jsonField, bool) {
+	var (
+		dominant jsonField
+		found    bool
+	)
+
+	for _, field := range level {
+		if field.name != name || !field.tagged {
+			continue
+		}
+
+		if found {
+			return jsonField{}, false
+		}
+		dominant, found = field, true
+	}
+
+	return dominant, found
+}
+
+func equalIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return len(a) < len(b)
+}
+
+func hasJSONOption(options, option string) bool {
+	for options != "" {
+		var current string
+		current, options, _ = strings.Cut(options, ",")
+		if current == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validJSONName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+
+	return true
+}
+
+// writeJSONString writes s quoted and escaped like encoding/json does,
+// including its escaping of HTML characters. Invalid UTF-8 is replaced with
+// U+FFFD.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+
+			buf.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[c>>4])
+				buf.WriteByte(hex[c&0xF])
+			}
+
+			i++
+			start = i
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case c == utf8.RuneError && size == 1:
+			buf.WriteString(s[start:i])
+			buf.WriteRune(utf8.RuneError)
+		case c == '\u2028' || c == '\u2029':
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hex[c&0xF])
+		default:
+			i += size
+			continue
+		}
+
+		i += size
+		start = i
+	}
+
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
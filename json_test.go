@@ -0,0 +1,272 @@
+package desensitivize
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jsonLevel int
+
+func (l jsonLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(l))), nil
+}
+
+type jsonSecret struct {
+	Value string `sensitive:"-"`
+}
+
+func (s *jsonSecret) MarshalJSON() ([]byte, error) {
+	return json.Marshal("secret:" + s.Value)
+}
+
+func TestMarshalJSON(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type (
+		Base struct {
+			ID    int    `json:"id"`
+			Token string `json:"token" sensitive:"-"`
+		}
+
+		Address struct {
+			Street string `json:"street" sensitive:"-"`
+			City   string `json:"city,omitempty"`
+		}
+
+		User struct {
+			Base
+			Name      string             `json:"name"`
+			Email     string             `json:"email,omitempty" sensitive:"-"`
+			Password  string             `json:"-" sensitive:"-"`
+			Age       int                `json:"age,string"`
+			Pin       string             `json:"pin,string" sensitive:"mask:last=2"`
+			Address   *Address           `json:"address,omitempty"`
+			Addresses []Address          `json:"addresses"`
+			Tokens    []string           `json:"tokens" sensitive:"each,mask:first=1"`
+			Headers   map[string]string  `json:"headers" sensitive:"entries=authorization"`
+			Meta      map[string]any     `json:"meta"`
+			Keyed     map[int]Address    `json:"keyed"`
+			Level     jsonLevel          `json:"level"`
+			Secret    jsonSecret         `json:"secret"`
+			Created   time.Time          `json:"created"`
+			Tags      map[string]Address `json:"tags,omitempty"`
+			Untagged  string
+			hidden    string
+		}
+	)
+
+	user := User{
+		Base:      Base{ID: 1, Token: "token"},
+		Name:      "John <Doe>",
+		Email:     "john@example.com",
+		Password:  "password",
+		Age:       42,
+		Pin:       "123456",
+		Address:   &Address{Street: "Main Street 1", City: "Springfield"},
+		Addresses: []Address{{Street: "a"}},
+		Tokens:    []string{"abc"},
+		Headers:   map[string]string{"Authorization": "Bearer x", "Accept": "*/*"},
+		Meta:      map[string]any{"address": Address{Street: "b", City: "c"}, "n": 1},
+		Keyed:     map[int]Address{1: {Street: "d"}},
+		Level:     3,
+		Secret:    jsonSecret{Value: "v"},
+		Created:   time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Untagged:  "untagged",
+		hidden:    "hidden",
+	}
+
+	encoded, err := MarshalJSON(user)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": 1,
+		"token": "",
+		"name": "John <Doe>",
+		"age": "42",
+		"pin": "\"****56\"",
+		"address": {"street": "", "city": "Springfield"},
+		"addresses": [{"street": ""}],
+		"tokens": ["a**"],
+		"headers": {"Authorization": "", "Accept": "*/*"},
+		"meta": {"address": {"street": "", "city": "c"}, "n": 1},
+		"keyed": {"1": {"street": ""}},
+		"level": "***",
+		"secret": {"Value": ""},
+		"created": "2022-01-02T03:04:05Z",
+		"Untagged": "untagged"
+	}`, string(encoded))
+
+	SetCopyMode(CopyOnWrite)
+	defer SetCopyMode(CopyDeep)
+
+	expected, err := json.Marshal(Redact(user))
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(encoded))
+
+	require.Equal(t, "token", user.Token)
+	require.Equal(t, "Main Street 1", user.Address.Street)
+}
+
+func TestMarshalJSONEmbedding(t *testing.T) {
+	type (
+		Inner struct {
+			Name  string `sensitive:"-"`
+			Other string
+		}
+
+		Tagged struct {
+			Name string `json:"Name"`
+		}
+
+		Outer struct {
+			*Inner
+			Tagged
+			Other string `json:"other"`
+		}
+	)
+
+	for _, obj := range []Outer{
+		{Inner: &Inner{Name: "a", Other: "b"}, Tagged: Tagged{Name: "c"}, Other: "d"},
+		{Tagged: Tagged{Name: "c"}},
+	} {
+		encoded, err := MarshalJSON(obj)
+		require.NoError(t, err)
+
+		expected, err := json.Marshal(obj)
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(encoded))
+	}
+}
+
+func TestMarshalJSONUnexportedEmbedding(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type (
+		inner struct {
+			Name  string `sensitive:"-"`
+			Other string
+		}
+
+		Outer struct {
+			inner
+		}
+	)
+
+	obj := Outer{inner{Name: "a", Other: "b"}}
+
+	// Redact can't walk the embedded struct, so it drops it, while
+	// MarshalJSON redacts its promoted fields one by one.
+	require.Equal(t, Outer{}, Redact(obj))
+
+	encoded, err := MarshalJSON(obj)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Name": "", "Other": "b"}`, string(encoded))
+}
+
+func TestMarshalJSONErrors(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type Broken struct {
+		Name string `sensitive:"truncate:x|mask"`
+	}
+
+	_, err := MarshalJSON(Broken{Name: "name"})
+	require.Error(t, err)
+
+	_, err = MarshalJSON(map[string]any{"ch": make(chan int)})
+	var unsupported *json.UnsupportedTypeError
+	require.True(t, errors.As(err, &unsupported))
+
+	type Node struct {
+		Secret string `sensitive:"-"`
+		Next   *Node
+	}
+
+	node := &Node{Secret: "s"}
+	node.Next = node
+
+	loop := map[string]any{}
+	loop["self"] = loop
+
+	list := []any{nil}
+	list[0] = list
+
+	for _, cyclic := range []any{node, loop, list} {
+		_, expected := json.Marshal(cyclic)
+		_, err = MarshalJSON(cyclic)
+
+		var cycle *json.UnsupportedValueError
+		require.True(t, errors.As(expected, &cycle))
+		require.True(t, errors.As(err, &cycle))
+	}
+}
+
+func TestWriteJSONString(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"plain",
+		"quote \" backslash \\ slash /",
+		"<html> & \u2028 \u2029",
+		"\n\r\t\x00\x1f\x7f",
+		"invalid \xff utf-8",
+		"ünïcödé 日本語 🙂",
+	} {
+		var buf bytes.Buffer
+		writeJSONString(&buf, text)
+
+		var decoded string
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		require.Equal(t, strings.ToValidUTF8(text, "\uFFFD"), decoded)
+
+		if utf8.ValidString(text) {
+			expected, err := json.Marshal(text)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), buf.String())
+		}
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type Event struct {
+		Name  string `json:"name"`
+		Token string `json:"token,omitempty" sensitive:"-"`
+	}
+
+	var out bytes.Buffer
+	enc := NewJSONEncoder(&out)
+	require.NoError(t, enc.Encode(Event{Name: "a", Token: "b"}))
+	require.NoError(t, enc.Encode(&Event{Name: "c"}))
+	require.NoError(t, enc.Encode(nil))
+	require.Equal(t, "{\"name\":\"a\"}\n{\"name\":\"c\"}\nnull\n", out.String())
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	response := benchResponseValue()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MarshalJSON(response)
+	}
+}
+
+func BenchmarkRedactAndMarshal(b *testing.B) {
+	response := benchResponseValue()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		json.Marshal(Redact(response))
+	}
+}
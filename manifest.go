@@ -0,0 +1,122 @@
+package desensitivize
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Manifest lists every path of T which is redacted, holds an untagged string
+// or an interface, one per line. Committed to the repository it makes
+// changes to the handling of sensitive data visible in code review.
+func Manifest[T any]() string {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	var manifest strings.Builder
+	fmt.Fprintf(&manifest, "# desensitivize manifest of %s\n", typ)
+	writeManifest(&manifest, typ, "", map[reflect.Type]bool{})
+
+	return manifest.String()
+}
+
+func writeManifest(manifest *strings.Builder, typ reflect.Type, path string, visiting map[reflect.Type]bool) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if _, handled := typeHandlers[typ]; handled {
+		fmt.Fprintf(manifest, "%s %s %s\n", manifestPath(path), typ, ActionBuiltin)
+		return
+	}
+
+	if visiting[typ] {
+		fmt.Fprintf(manifest, "%s %s recursive\n", manifestPath(path), typ)
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		fmt.Fprintf(manifest, "%s %s plain\n", manifestPath(path), typ)
+	case reflect.Interface:
+		fmt.Fprintf(manifest, "%s %s dynamic\n", manifestPath(path), typ)
+	case reflect.Slice, reflect.Array:
+		writeManifest(manifest, typ.Elem(), path+"[*]", visiting)
+	case reflect.Map:
+		if keyRulesApply(typ) {
+			fmt.Fprintf(manifest, "%s %s %s %s\n", manifestPath(path), typ, ActionKeyRules, strings.Join(keyRulePatterns(), "|"))
+		}
+
+		switch typ.Key().Kind() {
+		case reflect.Struct, reflect.Array, reflect.Pointer:
+			writeManifest(manifest, typ.Key(), path+"{key}", visiting)
+		}
+		writeManifest(manifest, typ.Elem(), path+"[*]", visiting)
+	case reflect.Struct:
+		visiting[typ] = true
+		for i := 0; i < typ.NumField(); i++ {
+			if !typ.Field(i).IsExported() {
+				if zeroed(typ, i) {
+					fmt.Fprintf(manifest, "%s %s %s\n", fieldPath(path, typ.Field(i).Name), typ.Field(i).Type, ActionZero)
+				}
+				continue
+			}
+
+			writeManifestField(manifest, typ, i, path, visiting)
+		}
+		delete(visiting, typ)
+	}
+}
+
+func writeManifestField(manifest *strings.Builder, structType reflect.Type, index int, parentPath string, visiting map[reflect.Type]bool) {
+	field := structType.Field(index)
+
+	path := field.Name
+	if parentPath != "" {
+		path = parentPath + "." + field.Name
+	}
+
+	tag, exist := lookupTag(structType, index)
+	if !exist {
+		writeManifest(manifest, field.Type, path, visiting)
+		return
+	}
+
+	spec := parseTag(tag)
+	action := fieldAction(field.Type, spec)
+
+	fmt.Fprintf(manifest, "%s %s %s", path, field.Type, action)
+	if action == ActionEntries {
+		fmt.Fprintf(manifest, " %s", strings.Join(spec.entries, "|"))
+	}
+	if spec.encoding != "" {
+		fmt.Fprintf(manifest, " in %s %s", spec.encoding, strings.Join(spec.selectors(), ","))
+	}
+	fmt.Fprintf(manifest, " %q", spec.strategy)
+	if spec.condition != "" {
+		fmt.Fprintf(manifest, " if %s", spec.condition)
+	}
+	manifest.WriteByte('\n')
+
+	switch {
+	case spec.condition != "":
+		writeManifest(manifest, field.Type, path, visiting)
+	case action == ActionKeys || action == ActionEntries:
+		writeManifest(manifest, field.Type.Elem(), path+"[*]", visiting)
+	}
+}
+
+func fieldPath(parentPath, name string) string {
+	if parentPath == "" {
+		return name
+	}
+
+	return parentPath + "." + name
+}
+
+func manifestPath(path string) string {
+	if path == "" {
+		return "."
+	}
+
+	return path
+}
@@ -0,0 +1,74 @@
+package desensitivize
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentField segmentKind = iota
+	segmentIndex
+	segmentKey
+	segmentMapKey
+)
+
+type pathSegment struct {
+	kind  segmentKind
+	field string
+	index int
+	key   reflect.Value
+}
+
+func (r *redactor) enterField(name string) {
+	r.path = append(r.path, pathSegment{kind: segmentField, field: name})
+}
+
+func (r *redactor) enterIndex(index int) {
+	r.path = append(r.path, pathSegment{kind: segmentIndex, index: index})
+}
+
+func (r *redactor) enterKey(key reflect.Value) {
+	r.path = append(r.path, pathSegment{kind: segmentKey, key: key})
+}
+
+func (r *redactor) enterMapKey() {
+	r.path = append(r.path, pathSegment{kind: segmentMapKey})
+}
+
+func (r *redactor) leave() {
+	r.path = r.path[:len(r.path)-1]
+}
+
+// currentPath formats the path like Users[3].Address, Meta["token"] or
+// Keys{key}.Name for fields of map keys.
+func (r *redactor) currentPath() string {
+	var path strings.Builder
+
+	for i, segment := range r.path {
+		switch segment.kind {
+		case segmentField:
+			if i > 0 {
+				path.WriteByte('.')
+			}
+			path.WriteString(segment.field)
+		case segmentIndex:
+			path.WriteByte('[')
+			path.WriteString(strconv.Itoa(segment.index))
+			path.WriteByte(']')
+		case segmentKey:
+			if segment.key.Kind() == reflect.String {
+				fmt.Fprintf(&path, "[%q]", segment.key.String())
+				continue
+			}
+			fmt.Fprintf(&path, "[%v]", segment.key)
+		case segmentMapKey:
+			path.WriteString("{key}")
+		}
+	}
+
+	return path.String()
+}
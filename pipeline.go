@@ -0,0 +1,235 @@
+package desensitivize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// StepFunc is a named step of a redaction pipeline such as
+// `sensitive:"trim|lower|hmac:k1|truncate=12"`. arg is whatever follows the
+// first ':' or '=' of the step, e.g. "12" for truncate=12.
+type StepFunc func(val reflect.Value, arg string) (reflect.Value, error)
+
+type pipelineStep struct {
+	name string
+	arg  string
+	fn   StepFunc
+}
+
+var (
+	steps    map[string]StepFunc
+	hmacKeys map[string][]byte
+
+	// readableSteps leave values readable, even if shortened, so a pipeline
+	// of nothing but them is rejected.
+	readableSteps map[string]bool
+)
+
+func init() {
+	steps = map[string]StepFunc{}
+	hmacKeys = map[string][]byte{}
+
+	RegisterStep("trim", StringStep(func(s, _ string) (string, error) {
+		return strings.TrimSpace(s), nil
+	}))
+	RegisterStep("lower", StringStep(func(s, _ string) (string, error) {
+		return strings.ToLower(s), nil
+	}))
+	RegisterStep("upper", StringStep(func(s, _ string) (string, error) {
+		return strings.ToUpper(s), nil
+	}))
+	RegisterStep("truncate", StringStep(truncateStep))
+	RegisterStep("mask", StringStep(maskStep))
+	RegisterStep("hmac", StringStep(hmacStep))
+	RegisterStep(urlStrategy, StringStep(urlStep))
+	RegisterStep(dsnStrategy, StringStep(dsnStep))
+
+	readableSteps = map[string]bool{"trim": true, "lower": true, "upper": true, "truncate": true}
+}
+
+// RegisterStep makes fn usable as a pipeline step under name, replacing any
+// step registered under the same name.
+func RegisterStep(name string, fn StepFunc) {
+	steps[name] = fn
+	delete(readableSteps, name)
+	invalidatePlans()
+}
+
+// SetHMACKey registers the key used by the `hmac:<id>` step.
+func SetHMACKey(id string, key []byte) {
+	hmacKeys[id] = key
+}
+
+// StringStep adapts a string transformation to a StepFunc. The step accepts
+// string kinds and pointers to them, leaving nil pointers untouched.
+func StringStep(fn func(s, arg string) (string, error)) StepFunc {
+	var step StepFunc
+	step = func(val reflect.Value, arg string) (reflect.Value, error) {
+		if val.Kind() == reflect.Pointer {
+			if val.IsNil() {
+				return val, nil
+			}
+
+			elem, err := step(val.Elem(), arg)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			tmpObj := reflect.New(elem.Type())
+			tmpObj.Elem().Set(elem)
+			return tmpObj, nil
+		}
+
+		if val.Kind() != reflect.String {
+			return reflect.Value{}, fmt.Errorf("expected a string, got %s", val.Type())
+		}
+
+		s, err := fn(val.String(), arg)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(s).Convert(val.Type()), nil
+	}
+
+	return step
+}
+
+// parsePipeline returns nil without an error when the strategy isn't a
+// pipeline but a custom redact key.
+func parsePipeline(strategy string) ([]pipelineStep, error) {
+	tokens := strings.Split(strategy, "|")
+	pipeline := make([]pipelineStep, 0, len(tokens))
+
+	for _, token := range tokens {
+		name, arg := token, ""
+		if i := strings.IndexAny(token, ":="); i >= 0 {
+			name, arg = token[:i], token[i+1:]
+		}
+
+		fn, exist := steps[name]
+		if !exist {
+			if len(tokens) == 1 {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("desensitivize: unknown step %q in %q", name, strategy)
+		}
+
+		pipeline = append(pipeline, pipelineStep{
+			name: name,
+			arg:  arg,
+			fn:   fn,
+		})
+	}
+
+	for _, step := range pipeline {
+		if !readableSteps[step.name] {
+			return pipeline, nil
+		}
+	}
+
+	return nil, fmt.Errorf("desensitivize: pipeline %q leaves values readable without redacting them", strategy)
+}
+
+func (r *redactor) redactValue(obj reflect.Value, strategy string) reflect.Value {
+	valType := obj.Type()
+	r.record(valType, strategy)
+
+	if _, exist := customRedacts[valType].specificVals[strategy]; exist {
+		return redactedValue(valType, strategy)
+	}
+
+	if strategy == placeholderStrategy {
+		return r.placeholder(obj)
+	}
+
+	pipeline, err := pipelineFor(strategy)
+	if err != nil {
+		r.fail(err)
+		return reflect.Zero(valType)
+	}
+
+	if pipeline == nil {
+		return redactedValue(valType, strategy)
+	}
+
+	for _, step := range pipeline {
+		obj, err = step.fn(obj, step.arg)
+		if err != nil {
+			r.fail(fmt.Errorf("desensitivize: step %q on %s: %w", step.name, valType, err))
+			return reflect.Zero(valType)
+		}
+	}
+
+	if !obj.Type().AssignableTo(valType) {
+		if !obj.Type().ConvertibleTo(valType) {
+			r.fail(fmt.Errorf("desensitivize: pipeline %q returned %s for %s", strategy, obj.Type(), valType))
+			return reflect.Zero(valType)
+		}
+		obj = obj.Convert(valType)
+	}
+
+	return obj
+}
+
+func truncateStep(s, arg string) (string, error) {
+	length, err := strconv.Atoi(arg)
+	if err != nil || length < 0 {
+		return "", fmt.Errorf("invalid length %q", arg)
+	}
+
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s, nil
+	}
+
+	return string(runes[:length]), nil
+}
+
+func maskStep(s, arg string) (string, error) {
+	runes := []rune(s)
+	keepFirst, keepLast := 0, 0
+
+	if arg != "" {
+		side, count, found := strings.Cut(arg, "=")
+		keep, err := strconv.Atoi(count)
+		if !found || err != nil || keep < 0 {
+			return "", fmt.Errorf("invalid mask %q", arg)
+		}
+
+		switch side {
+		case "first":
+			keepFirst = keep
+		case "last":
+			keepLast = keep
+		default:
+			return "", fmt.Errorf("invalid mask %q", arg)
+		}
+	}
+
+	for i := range runes {
+		if i < keepFirst || i >= len(runes)-keepLast {
+			continue
+		}
+		runes[i] = '*'
+	}
+
+	return string(runes), nil
+}
+
+func hmacStep(s, arg string) (string, error) {
+	key, exist := hmacKeys[arg]
+	if !exist {
+		return "", fmt.Errorf("unknown hmac key %q", arg)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,119 @@
+package desensitivize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	hmacKeys = map[string][]byte{}
+
+	type (
+		Email string
+
+		RedactStruct struct {
+			Email    Email    `sensitive:"trim|lower|hmac:k1|truncate=12"`
+			Card     string   `sensitive:"mask:last=4"`
+			PName    *string  `sensitive:"upper|mask:first=1"`
+			Tokens   []string `sensitive:"each,mask"`
+			Custom   string   `sensitive:"lower|custom"`
+			Legacy   string   `sensitive:"custom"`
+			Override string   `sensitive:"lower"`
+		}
+	)
+
+	SetHMACKey("k1", []byte("key"))
+	SetCustomRedact("custom", "[CUSTOM]")
+	SetCustomRedact("lower", "[LOWER]")
+	RegisterStep("custom", StringStep(func(s, _ string) (string, error) {
+		return s + "!", nil
+	}))
+	defer delete(steps, "custom")
+
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte("john@example.com"))
+	expectedEmail := hex.EncodeToString(mac.Sum(nil))[:12]
+
+	obj := RedactStruct{
+		Email:    " John@Example.com ",
+		Card:     "4111111111111111",
+		PName:    vToP("john"),
+		Tokens:   []string{"abc", "de"},
+		Custom:   "ABC",
+		Legacy:   "legacy",
+		Override: "ABC",
+	}
+
+	redacted, _, err := TryRedact(obj)
+	require.Nil(t, err)
+	require.Equal(t, RedactStruct{
+		Email:    Email(expectedEmail),
+		Card:     "************1111",
+		PName:    vToP("J***"),
+		Tokens:   []string{"***", "**"},
+		Custom:   "abc!",
+		Legacy:   "[CUSTOM]",
+		Override: "[LOWER]",
+	}, redacted)
+}
+
+func TestPipelineErrors(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	hmacKeys = map[string][]byte{}
+
+	type (
+		UnknownStep struct {
+			F1 string `sensitive:"trim|unknown"`
+		}
+
+		UnknownKey struct {
+			F1 string `sensitive:"hmac:missing"`
+		}
+
+		InvalidArg struct {
+			F1 string `sensitive:"truncate=many|mask"`
+		}
+
+		NotAString struct {
+			F1 int `sensitive:"mask"`
+		}
+
+		OnlyNormalized struct {
+			F1 string `sensitive:"trim|lower"`
+		}
+
+		OnlyTruncated struct {
+			F1 string `sensitive:"truncate=3"`
+		}
+	)
+
+	redactedStep, _, err := TryRedact(UnknownStep{F1: "F1"})
+	require.EqualError(t, err, `desensitivize: unknown step "unknown" in "trim|unknown"`)
+	require.Equal(t, UnknownStep{}, redactedStep)
+
+	redactedKey, _, err := TryRedact(UnknownKey{F1: "F1"})
+	require.EqualError(t, err, `desensitivize: step "hmac" on string: unknown hmac key "missing"`)
+	require.Equal(t, UnknownKey{}, redactedKey)
+
+	redactedArg, _, err := TryRedact(InvalidArg{F1: "F1"})
+	require.EqualError(t, err, `desensitivize: step "truncate" on string: invalid length "many"`)
+	require.Equal(t, InvalidArg{}, redactedArg)
+
+	redactedInt, _, err := TryRedact(NotAString{F1: 1})
+	require.EqualError(t, err, `desensitivize: step "mask" on int: expected a string, got int`)
+	require.Equal(t, NotAString{}, redactedInt)
+
+	redactedNormalized, _, err := TryRedact(OnlyNormalized{F1: " John "})
+	require.EqualError(t, err, `desensitivize: pipeline "trim|lower" leaves values readable without redacting them`)
+	require.Equal(t, OnlyNormalized{}, redactedNormalized)
+
+	redactedTruncated, _, err := TryRedact(OnlyTruncated{F1: "John"})
+	require.EqualError(t, err, `desensitivize: pipeline "truncate=3" leaves values readable without redacting them`)
+	require.Equal(t, OnlyTruncated{}, redactedTruncated)
+}
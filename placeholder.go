@@ -0,0 +1,71 @@
+package desensitivize
+
+import (
+	"fmt"
+	"reflect"
+)
+
+const placeholderStrategy = "placeholder"
+
+// Placeholder describes a value redacted by the placeholder strategy which
+// couldn't hold the placeholder text itself and was zeroed instead.
+type Placeholder struct {
+	Path string
+	Type string
+	// Len is the length of slices, arrays and maps, -1 for other types.
+	Len int
+}
+
+func (r *redactor) placeholder(obj reflect.Value) reflect.Value {
+	objType := obj.Type()
+
+	switch obj.Kind() {
+	case reflect.String:
+		text := fmt.Sprintf("[REDACTED %s len=%d]", objType, obj.Len())
+		return reflect.ValueOf(text).Convert(objType)
+	case reflect.Pointer:
+		if obj.IsNil() {
+			return obj
+		}
+
+		tmpObj := reflect.New(objType.Elem())
+		tmpObj.Elem().Set(r.placeholder(obj.Elem()))
+		return tmpObj
+	case reflect.Slice:
+		if obj.IsNil() {
+			return obj
+		}
+
+		if objType.Elem().Kind() == reflect.String {
+			text := fmt.Sprintf("[REDACTED %d items]", obj.Len())
+			redactedSlice := reflect.MakeSlice(objType, 1, 1)
+			redactedSlice.Index(0).Set(reflect.ValueOf(text).Convert(objType.Elem()))
+			return redactedSlice
+		}
+	case reflect.Map:
+		if obj.IsNil() {
+			return obj
+		}
+
+		if objType.Key().Kind() == reflect.String {
+			text := fmt.Sprintf("[REDACTED %d items]", obj.Len())
+			redactedMap := reflect.MakeMapWithSize(objType, 1)
+			redactedMap.SetMapIndex(reflect.ValueOf(text).Convert(objType.Key()), reflect.Zero(objType.Elem()))
+			return redactedMap
+		}
+	}
+
+	length := -1
+	switch obj.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		length = obj.Len()
+	}
+
+	r.stats.Placeholders = append(r.stats.Placeholders, Placeholder{
+		Path: r.currentPath(),
+		Type: objType.String(),
+		Len:  length,
+	})
+
+	return reflect.Zero(objType)
+}
@@ -0,0 +1,59 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceholder(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type (
+		Address struct {
+			Street string
+		}
+
+		RedactStruct struct {
+			Name     string            `sensitive:"placeholder"`
+			Empty    string            `sensitive:"placeholder"`
+			PName    *string           `sensitive:"placeholder"`
+			NilName  *string           `sensitive:"placeholder"`
+			Tags     []string          `sensitive:"placeholder"`
+			Settings map[string]int    `sensitive:"placeholder"`
+			Age      int               `sensitive:"placeholder"`
+			Ids      []int             `sensitive:"placeholder"`
+			Address  Address           `sensitive:"placeholder"`
+			Headers  map[string]string `sensitive:"entries=authorization,placeholder"`
+		}
+	)
+
+	obj := RedactStruct{
+		Name:     "John Doe",
+		PName:    vToP("John"),
+		Tags:     []string{"a", "b", "c", "d"},
+		Settings: map[string]int{"a": 1, "b": 2},
+		Age:      42,
+		Ids:      []int{1, 2},
+		Address:  Address{Street: "street"},
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	}
+
+	redacted, stats, err := TryRedact(obj)
+	require.Nil(t, err)
+	require.Equal(t, RedactStruct{
+		Name:     "[REDACTED string len=8]",
+		Empty:    "[REDACTED string len=0]",
+		PName:    vToP("[REDACTED string len=4]"),
+		Tags:     []string{"[REDACTED 4 items]"},
+		Settings: map[string]int{"[REDACTED 2 items]": 0},
+		Headers:  map[string]string{"Authorization": "[REDACTED string len=12]"},
+	}, redacted)
+
+	require.Equal(t, []Placeholder{
+		{Path: "Age", Type: "int", Len: -1},
+		{Path: "Ids", Type: "[]int", Len: 2},
+		{Path: "Address", Type: "desensitivize.Address", Len: -1},
+	}, stats.Placeholders)
+}
@@ -0,0 +1,278 @@
+package desensitivize
+
+import (
+	"reflect"
+	"sync"
+)
+
+type typePlan struct {
+	// sensitive tells whether values of the type may need redaction at all.
+	sensitive bool
+	// fields lists the struct fields which are tagged or may contain
+	// sensitive data. Other fields are left as they are.
+	fields []fieldPlan
+	// unexported lists the unexported struct fields which are tagged or lead
+	// to tagged fields. They can't be walked, so they are zeroed.
+	unexported []int
+	// dynamic lists the other unexported interface fields. What they hold is
+	// only known at runtime, so they are zeroed if it needs redaction.
+	dynamic []int
+	// conditional tells whether any field is redacted conditionally.
+	conditional bool
+	// handler redacts values of types with their own handling, such as
+	// url.URL.
+	handler typeHandler
+	// opaque tells whether the type is a struct with only unexported fields
+	// which don't lead to tags, such as the errors of the standard library.
+	// Pointers to them are shared even by deep copies, so errors.Is keeps
+	// working on the result.
+	opaque bool
+	// cyclic tells whether values of a pointer type may point back to
+	// themselves, so the walkers have to remember where they have been.
+	cyclic bool
+}
+
+// typeHandler redacts a value of a type with its own handling, returning it
+// like the handlers of the redactor do.
+type typeHandler func(r *redactor, obj reflect.Value) (reflect.Value, bool)
+
+var (
+	typeHandlers = map[reflect.Type]typeHandler{}
+	// handledFields lists the only fields of struct types with their own
+	// handling which are redacted, and so deep copied.
+	handledFields = map[reflect.Type][]string{}
+)
+
+type fieldPlan struct {
+	index   int
+	name    string
+	tagged  bool
+	tag     string
+	spec    tagSpec
+	cond    condition
+	condErr error
+}
+
+var (
+	plans     sync.Map
+	pipelines sync.Map
+)
+
+type compiledPipeline struct {
+	steps []pipelineStep
+	err   error
+}
+
+func planFor(typ reflect.Type) *typePlan {
+	if plan, exist := plans.Load(typ); exist {
+		return plan.(*typePlan)
+	}
+
+	plan, _ := plans.LoadOrStore(typ, compilePlan(typ))
+	return plan.(*typePlan)
+}
+
+func compilePlan(typ reflect.Type) *typePlan {
+	plan := &typePlan{
+		sensitive: mayRedact(typ, map[reflect.Type]bool{}),
+		handler:   typeHandlers[typ],
+		opaque:    opaque(typ),
+	}
+
+	if plan.sensitive && typ.Kind() == reflect.Pointer {
+		plan.cyclic = leadsTo(typ.Elem(), typ, map[reflect.Type]bool{})
+	}
+
+	if !plan.sensitive || typ.Kind() != reflect.Struct {
+		return plan
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		tag, tagged := lookupTag(typ, i)
+		if !tagged && !mayRedact(field.Type, map[reflect.Type]bool{}) {
+			continue
+		}
+
+		if !field.IsExported() {
+			switch {
+			case tagged || reachesTag(field.Type, map[reflect.Type]bool{}):
+				plan.unexported = append(plan.unexported, i)
+			case field.Type.Kind() == reflect.Interface:
+				plan.dynamic = append(plan.dynamic, i)
+			}
+			continue
+		}
+
+		fieldPlan := fieldPlan{
+			index:  i,
+			name:   field.Name,
+			tagged: tagged,
+			tag:    tag,
+		}
+
+		if tagged {
+			fieldPlan.spec = parseTag(tag)
+			if fieldPlan.spec.condition != "" {
+				plan.conditional = true
+				fieldPlan.cond, fieldPlan.condErr = parseCondition(fieldPlan.spec.condition)
+			}
+		}
+
+		plan.fields = append(plan.fields, fieldPlan)
+	}
+
+	return plan
+}
+
+func opaque(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || typ.NumField() == 0 {
+		return false
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).IsExported() {
+			return false
+		}
+	}
+
+	return !mayRedact(typ, map[reflect.Type]bool{})
+}
+
+// leadsTo tells whether the walkers may reach a value of target from typ.
+// Interfaces may hold anything.
+func leadsTo(typ, target reflect.Type, seen map[reflect.Type]bool) bool {
+	if typ == target {
+		return true
+	}
+
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return leadsTo(typ.Elem(), target, seen)
+	case reflect.Map:
+		return leadsTo(typ.Key(), target, seen) || leadsTo(typ.Elem(), target, seen)
+	case reflect.Struct:
+		if _, handled := typeHandlers[typ]; handled {
+			return false
+		}
+
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() && field.Type.Kind() != reflect.Interface {
+				continue
+			}
+
+			if leadsTo(field.Type, target, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// mayRedact tells whether any value reachable from typ is redacted.
+// Interfaces may hold anything. Unexported fields count if they lead to tags
+// or are interfaces, since they may be zeroed then.
+func mayRedact(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	return reaches(typ, true, seen)
+}
+
+// reachesTag tells whether typ leads to a tagged field or a type with its own
+// handling without going through interfaces, whose values are only known at
+// runtime. Other unexported fields are zeroed only if they are interfaces
+// holding sensitive data, so that e.g. a wrapped error keeps its chain.
+func reachesTag(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	return reaches(typ, false, seen)
+}
+
+func reaches(typ reflect.Type, dynamic bool, seen map[reflect.Type]bool) bool {
+	if plan, exist := plans.Load(typ); exist && dynamic {
+		return plan.(*typePlan).sensitive
+	}
+
+	if _, handled := typeHandlers[typ]; handled {
+		return true
+	}
+
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		return dynamic
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return reaches(typ.Elem(), dynamic, seen)
+	case reflect.Map:
+		// Key rules aren't tags, so they don't make unexported maps zeroed.
+		if dynamic && keyRulesApply(typ) {
+			return true
+		}
+
+		switch typ.Key().Kind() {
+		case reflect.Struct, reflect.Array, reflect.Pointer:
+			if reaches(typ.Key(), dynamic, seen) {
+				return true
+			}
+		}
+		return reaches(typ.Elem(), dynamic, seen)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if _, exist := lookupTag(typ, i); exist {
+				return true
+			}
+
+			field := typ.Field(i)
+			if !field.IsExported() && dynamic {
+				if field.Type.Kind() == reflect.Interface || reachesTag(field.Type, map[reflect.Type]bool{}) {
+					return true
+				}
+				continue
+			}
+
+			if reaches(field.Type, dynamic, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func pipelineFor(strategy string) ([]pipelineStep, error) {
+	if pipeline, exist := pipelines.Load(strategy); exist {
+		compiled := pipeline.(compiledPipeline)
+		return compiled.steps, compiled.err
+	}
+
+	steps, err := parsePipeline(strategy)
+	pipelines.Store(strategy, compiledPipeline{
+		steps: steps,
+		err:   err,
+	})
+
+	return steps, err
+}
+
+// invalidatePlans drops the compiled plans after a configuration change.
+func invalidatePlans() {
+	plans.Range(func(key, _ any) bool {
+		plans.Delete(key)
+		return true
+	})
+
+	pipelines.Range(func(key, _ any) bool {
+		pipelines.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,143 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	benchAddress struct {
+		Street string `sensitive:"-"`
+		City   string
+		Zip    string
+	}
+
+	benchUser struct {
+		ID       int
+		Name     string
+		Email    string `sensitive:"-"`
+		Address  benchAddress
+		Tags     []string
+		Settings map[string]string
+	}
+
+	benchResponse struct {
+		Users []benchUser
+		Total int
+	}
+
+	benchPlainUser struct {
+		ID       int
+		Name     string
+		Tags     []string
+		Settings map[string]string
+	}
+
+	benchPlainResponse struct {
+		Users []benchPlainUser
+		Total int
+	}
+)
+
+func benchResponseValue() benchResponse {
+	response := benchResponse{Total: 100}
+	for i := 0; i < 100; i++ {
+		response.Users = append(response.Users, benchUser{
+			ID:    i,
+			Name:  "John Doe",
+			Email: "john@example.com",
+			Address: benchAddress{
+				Street: "Main Street 1",
+				City:   "Springfield",
+				Zip:    "12345",
+			},
+			Tags:     []string{"a", "b", "c"},
+			Settings: map[string]string{"theme": "dark", "lang": "en"},
+		})
+	}
+
+	return response
+}
+
+func benchPlainResponseValue() benchPlainResponse {
+	response := benchPlainResponse{Total: 100}
+	for i := 0; i < 100; i++ {
+		response.Users = append(response.Users, benchPlainUser{
+			ID:       i,
+			Name:     "John Doe",
+			Tags:     []string{"a", "b", "c"},
+			Settings: map[string]string{"theme": "dark", "lang": "en"},
+		})
+	}
+
+	return response
+}
+
+func BenchmarkRedact(b *testing.B) {
+	response := benchResponseValue()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Redact(response)
+	}
+}
+
+func BenchmarkRedactNothingSensitive(b *testing.B) {
+	response := benchPlainResponseValue()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Redact(response)
+	}
+}
+
+func TestPlanSkipsInsensitiveValues(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	response := benchPlainResponseValue()
+
+	redacted := Redact(response)
+	require.Equal(t, reflect.ValueOf(response.Users).Pointer(), reflect.ValueOf(redacted.Users).Pointer())
+
+	pResponse := &response
+	require.Equal(t, pResponse, Redact(pResponse))
+
+	settings := map[string]string{"k": "v"}
+	require.Equal(t, reflect.ValueOf(settings).Pointer(), reflect.ValueOf(Redact(settings)).Pointer())
+
+	plan := planFor(reflect.TypeOf(benchUser{}))
+	require.True(t, plan.sensitive)
+	require.Equal(t, []string{"Email", "Address"}, planFieldNames(plan))
+
+	require.False(t, planFor(reflect.TypeOf(benchPlainUser{})).sensitive)
+	require.True(t, planFor(reflect.TypeOf(struct{ A any }{})).sensitive)
+	require.True(t, planFor(reflect.TypeOf(struct{ a benchAddress }{})).sensitive)
+}
+
+func TestPlanInvalidation(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	fieldPolicies = map[reflect.Type]map[int]string{}
+
+	type RedactStruct struct {
+		F1 string
+	}
+
+	obj := RedactStruct{F1: "F1"}
+	require.Equal(t, obj, Redact(obj))
+
+	Policy[RedactStruct]().Field(func(r *RedactStruct) any { return &r.F1 }).Mask()
+	require.Equal(t, RedactStruct{}, Redact(obj))
+}
+
+func planFieldNames(plan *typePlan) []string {
+	var names []string
+	for _, field := range plan.fields {
+		names = append(names, field.name)
+	}
+
+	return names
+}
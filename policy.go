@@ -0,0 +1,118 @@
+package desensitivize
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var fieldPolicies map[reflect.Type]map[int]string
+
+func init() {
+	fieldPolicies = map[reflect.Type]map[int]string{}
+}
+
+// PolicyBuilder registers redaction rules for fields of T without touching
+// its struct tags. Rules apply exactly as if the field carried a `sensitive` tag.
+type PolicyBuilder[T any] struct {
+	typ reflect.Type
+}
+
+// FieldPolicy is a field selected by PolicyBuilder.Field awaiting a strategy.
+type FieldPolicy[T any] struct {
+	builder *PolicyBuilder[T]
+	owner   reflect.Type
+	index   int
+}
+
+// Policy starts a policy for the struct type T. It panics if T is not a struct.
+// Rules are registered globally for the struct type declaring the field, like
+// a struct tag, so they change how that type is redacted everywhere in the
+// process. Two policies selecting the same field share it and the rule set
+// last wins; there is no way to scope or remove a rule.
+func Policy[T any]() *PolicyBuilder[T] {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("desensitivize: policy type %s is not a struct", typ))
+	}
+
+	return &PolicyBuilder[T]{typ: typ}
+}
+
+// Field selects the field whose address the selector returns, e.g.
+// func(t *T) any { return &t.Secret }. The field is resolved by its offset
+// inside T, so it may live in a nested or embedded struct as long as it is
+// reachable without following a pointer. The rule is attached to the struct
+// type declaring the field. Field panics if the selector returns anything else.
+func (p *PolicyBuilder[T]) Field(selector func(t *T) any) *FieldPolicy[T] {
+	base := new(T)
+	fieldPtr := reflect.ValueOf(selector(base))
+	if fieldPtr.Kind() != reflect.Pointer || fieldPtr.IsNil() {
+		panic(fmt.Sprintf("desensitivize: selector for %s must return a field address", p.typ))
+	}
+
+	basePtr := reflect.ValueOf(base).Pointer()
+	if fieldPtr.Pointer() < basePtr || fieldPtr.Pointer() >= basePtr+p.typ.Size() {
+		panic(fmt.Sprintf("desensitivize: selector for %s returned an address outside of it", p.typ))
+	}
+
+	owner, index, found := resolveField(p.typ, fieldPtr.Pointer()-basePtr, fieldPtr.Type().Elem())
+	if !found {
+		panic(fmt.Sprintf("desensitivize: selector for %s does not point to a field", p.typ))
+	}
+
+	return &FieldPolicy[T]{
+		builder: p,
+		owner:   owner,
+		index:   index,
+	}
+}
+
+// Mask redacts the field with the default redaction of its type.
+func (f *FieldPolicy[T]) Mask() *PolicyBuilder[T] {
+	return f.Tag("-")
+}
+
+// Tag redacts the field as if it was tagged with `sensitive:"<value>"`.
+func (f *FieldPolicy[T]) Tag(value string) *PolicyBuilder[T] {
+	policies, exist := fieldPolicies[f.owner]
+	if !exist {
+		policies = map[int]string{}
+	}
+
+	policies[f.index] = value
+	fieldPolicies[f.owner] = policies
+	invalidatePlans()
+
+	return f.builder
+}
+
+func resolveField(structType reflect.Type, offset uintptr, fieldType reflect.Type) (reflect.Type, int, bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Offset == offset && field.Type == fieldType {
+			return structType, i, true
+		}
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Type.Kind() != reflect.Struct {
+			continue
+		}
+
+		if offset < field.Offset || offset >= field.Offset+field.Type.Size() {
+			continue
+		}
+
+		if owner, index, found := resolveField(field.Type, offset-field.Offset, fieldType); found {
+			return owner, index, true
+		}
+	}
+
+	return nil, 0, false
+}
+
+func lookupPolicy(structType reflect.Type, index int) (string, bool) {
+	tag, exist := fieldPolicies[structType][index]
+	return tag, exist
+}
@@ -0,0 +1,132 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	fieldPolicies = map[reflect.Type]map[int]string{}
+
+	type (
+		Credentials struct {
+			User     string
+			Password string
+		}
+
+		Embedded struct {
+			Token string
+		}
+
+		Account struct {
+			Embedded
+			ID          int
+			Secret      string
+			Credentials Credentials
+			Note        string
+		}
+	)
+
+	Policy[Account]().
+		Field(func(a *Account) any { return &a.Secret }).Mask().
+		Field(func(a *Account) any { return &a.Token }).Mask().
+		Field(func(a *Account) any { return &a.Credentials.Password }).Mask().
+		Field(func(a *Account) any { return &a.Note }).Tag("note")
+
+	SetCustomRedact("note", "[NOTE]")
+
+	obj := Account{
+		Embedded: Embedded{
+			Token: "token",
+		},
+		ID:     1,
+		Secret: "secret",
+		Credentials: Credentials{
+			User:     "user",
+			Password: "password",
+		},
+		Note: "note",
+	}
+
+	redacted := Redact(obj)
+	require.Equal(t, Account{
+		ID: 1,
+		Credentials: Credentials{
+			User: "user",
+		},
+		Note: "[NOTE]",
+	}, redacted)
+
+	require.Equal(t, "secret", obj.Secret)
+}
+
+func TestPolicyOverridesTag(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	fieldPolicies = map[reflect.Type]map[int]string{}
+
+	type RedactStruct struct {
+		F1 string `sensitive:"-"`
+	}
+
+	SetCustomRedact("custom", "[CUSTOM]")
+	Policy[RedactStruct]().Field(func(r *RedactStruct) any { return &r.F1 }).Tag("custom")
+
+	redacted := Redact(RedactStruct{F1: "value"})
+	require.Equal(t, RedactStruct{F1: "[CUSTOM]"}, redacted)
+}
+
+func TestPolicySharedByType(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	fieldPolicies = map[reflect.Type]map[int]string{}
+
+	type (
+		Address struct {
+			Street string
+			City   string
+		}
+
+		Customer struct {
+			Address Address
+		}
+
+		Supplier struct {
+			Address Address
+		}
+	)
+
+	SetCustomRedact("street", "[STREET]")
+	Policy[Customer]().Field(func(c *Customer) any { return &c.Address.Street }).Mask()
+	Policy[Supplier]().Field(func(s *Supplier) any { return &s.Address.Street }).Tag("street")
+
+	address := Address{Street: "street", City: "city"}
+	require.Equal(t, Customer{Address: Address{Street: "[STREET]", City: "city"}}, Redact(Customer{Address: address}))
+	require.Equal(t, Supplier{Address: Address{Street: "[STREET]", City: "city"}}, Redact(Supplier{Address: address}))
+	require.Equal(t, Address{Street: "[STREET]", City: "city"}, Redact(address))
+}
+
+func TestPolicyInvalidSelector(t *testing.T) {
+	fieldPolicies = map[reflect.Type]map[int]string{}
+
+	type RedactStruct struct {
+		F1 string
+		F2 *string
+	}
+
+	outside := ""
+
+	require.Panics(t, func() {
+		Policy[string]()
+	})
+	require.Panics(t, func() {
+		Policy[RedactStruct]().Field(func(r *RedactStruct) any { return r.F1 })
+	})
+	require.Panics(t, func() {
+		Policy[RedactStruct]().Field(func(r *RedactStruct) any { return &outside })
+	})
+	require.Panics(t, func() {
+		Policy[RedactStruct]().Field(func(r *RedactStruct) any { return r.F2 })
+	})
+}
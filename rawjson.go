@@ -0,0 +1,197 @@
+package desensitivize
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+)
+
+var (
+	jsonObjectType = reflect.TypeOf(map[string]any{})
+	jsonArrayType  = reflect.TypeOf([]any{})
+	jsonAnyType    = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// RedactJSON redacts a raw JSON document by rules. Strings selected by a
+// PathRule are redacted with its strategy like string fields, other selected
+// values, including whole objects and arrays, become null. The document is
+// streamed token by token instead of being unmarshaled, and written back
+// compacted. Like TryRedact it fails when the redaction does.
+func RedactJSON(data []byte, rules ...Rule) ([]byte, error) {
+	if err := checkRules(rules); err != nil {
+		return nil, err
+	}
+
+	r := &redactor{}
+	redacted, err := r.redactJSON(data, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return redacted, nil
+}
+
+// redactJSON redacts a document found at the current path, which the
+// selectors of the rules are relative to.
+func (r *redactor) redactJSON(data []byte, rules []Rule) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	d := &jsonDocument{
+		r:     r,
+		dec:   dec,
+		rules: rules,
+	}
+
+	root := r.root
+	r.root = len(r.path)
+	defer func() { r.root = root }()
+
+	if err := d.value(); err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("desensitivize: invalid data after top-level JSON value")
+	}
+
+	return d.buf.Bytes(), nil
+}
+
+type jsonDocument struct {
+	r     *redactor
+	dec   *json.Decoder
+	rules []Rule
+	buf   bytes.Buffer
+}
+
+func (d *jsonDocument) value() error {
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if rule, matched := d.r.matchPath(d.rules); matched {
+		return d.redact(token, rule.strategy)
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '{' {
+			return d.object()
+		}
+		return d.array()
+	case string:
+		writeJSONString(&d.buf, d.r.redactString(token, d.rules))
+	case json.Number:
+		d.buf.WriteString(token.String())
+	case bool:
+		if token {
+			d.buf.WriteString("true")
+		} else {
+			d.buf.WriteString("false")
+		}
+	case nil:
+		d.buf.WriteString("null")
+	}
+
+	return nil
+}
+
+func (d *jsonDocument) object() error {
+	d.buf.WriteByte('{')
+
+	for first := true; d.dec.More(); first = false {
+		token, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key := token.(string)
+		if !first {
+			d.buf.WriteByte(',')
+		}
+		writeJSONString(&d.buf, key)
+		d.buf.WriteByte(':')
+
+		d.r.enterField(key)
+		err = d.value()
+		d.r.leave()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	d.buf.WriteByte('}')
+	_, err := d.dec.Token()
+	return err
+}
+
+func (d *jsonDocument) array() error {
+	d.buf.WriteByte('[')
+
+	for i := 0; d.dec.More(); i++ {
+		if i > 0 {
+			d.buf.WriteByte(',')
+		}
+
+		d.r.enterIndex(i)
+		err := d.value()
+		d.r.leave()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	d.buf.WriteByte(']')
+	_, err := d.dec.Token()
+	return err
+}
+
+// redact writes the redacted value starting with token, skipping the rest of
+// it.
+func (d *jsonDocument) redact(token json.Token, strategy string) error {
+	if text, isString := token.(string); isString {
+		redacted := d.r.redactValue(reflect.ValueOf(text), strategy)
+		writeJSONString(&d.buf, redacted.String())
+		return nil
+	}
+
+	valType := jsonAnyType
+	switch token := token.(type) {
+	case json.Delim:
+		valType = jsonArrayType
+		if token == '{' {
+			valType = jsonObjectType
+		}
+
+		for depth := 1; depth > 0; {
+			next, err := d.dec.Token()
+			if err != nil {
+				return err
+			}
+
+			switch next {
+			case json.Delim('{'), json.Delim('['):
+				depth++
+			case json.Delim('}'), json.Delim(']'):
+				depth--
+			}
+		}
+	case nil:
+	default:
+		valType = reflect.TypeOf(token)
+	}
+
+	d.r.record(valType, strategy)
+	d.buf.WriteString("null")
+	return nil
+}
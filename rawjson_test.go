@@ -0,0 +1,150 @@
+package desensitivize
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactJSON(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	document := `{
+		"user": {"name": "John", "password": "hunter2", "age": 42},
+		"items": [
+			{"card": "4111111111111111", "qty": 1},
+			{"card": {"number": "5500000000000004"}, "qty": 2}
+		],
+		"auth": {"token": "abc", "nested": [{"token": 7}]},
+		"token": true,
+		"notes": "call 555-0100 or 555-0199",
+		"html": "<b>&</b>"
+	}`
+
+	redacted, err := RedactJSON([]byte(document),
+		PathRule("$.user.password", "-"),
+		PathRule("$..token", "placeholder"),
+		PathRule("$.items[*].card", "mask:last=4"),
+		PathRule("$.user['age']", "-"),
+		ValueRule(regexp.MustCompile(`\d{3}-\d{4}`), "mask"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `{"user":{"name":"John","password":"","age":null},`+
+		`"items":[{"card":"************1111","qty":1},{"card":null,"qty":2}],`+
+		`"auth":{"token":"[REDACTED string len=3]","nested":[{"token":null}]},`+
+		`"token":null,`+
+		`"notes":"call ******** or ********",`+
+		`"html":"\u003cb\u003e\u0026\u003c/b\u003e"}`, string(redacted))
+}
+
+func TestRedactJSONReport(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	var events []Event
+	redactHooks = append(redactHooks, func(event Event) {
+		events = append(events, event)
+	})
+	defer func() { redactHooks = nil }()
+
+	_, err := RedactJSON([]byte(`[{"a": {"b": 1}}, {"a": "x"}]`), PathRule("$[*].a", "-"))
+	require.NoError(t, err)
+	require.Equal(t, []Event{
+		{Path: "[0].a", Strategy: "-", Type: "map[string]interface {}"},
+		{Path: "[1].a", Strategy: "-", Type: "string"},
+	}, events)
+}
+
+func TestRedactJSONErrors(t *testing.T) {
+	for _, rule := range []Rule{
+		PathRule("user", "-"),
+		PathRule("$.", "-"),
+		PathRule("$[x]", "-"),
+		PathRule("$['a", "-"),
+		PathRule("$[1", "-"),
+	} {
+		_, err := RedactJSON([]byte(`{}`), rule)
+		require.Error(t, err, rule.selector)
+	}
+
+	for _, document := range []string{`{"a":`, `{"a" 1}`, `{} {}`, ``} {
+		_, err := RedactJSON([]byte(document))
+		require.Error(t, err, document)
+	}
+
+	_, err := RedactJSON([]byte(`{"a":"b"}`), PathRule("$.a", "truncate:x|mask"))
+	require.Error(t, err)
+}
+
+func TestMatchSelector(t *testing.T) {
+	path := func(segments ...any) []pathSegment {
+		var result []pathSegment
+		for _, segment := range segments {
+			switch segment := segment.(type) {
+			case int:
+				result = append(result, pathSegment{kind: segmentIndex, index: segment})
+			case string:
+				result = append(result, pathSegment{kind: segmentField, field: segment})
+			}
+		}
+		return result
+	}
+
+	for selector, cases := range map[string]map[bool][][]pathSegment{
+		"$": {
+			true:  {path()},
+			false: {path("a")},
+		},
+		"$.a.b": {
+			true:  {path("a", "b")},
+			false: {path("a"), path("a", "b", "c"), path("b", "a", "b")},
+		},
+		"$..b": {
+			true:  {path("b"), path("a", "b"), path("a", 1, "b")},
+			false: {path("b", "c"), path("a")},
+		},
+		"$.a[*].b": {
+			true:  {path("a", 0, "b"), path("a", "x", "b")},
+			false: {path("a", "b")},
+		},
+		"$.a[1]": {
+			true:  {path("a", 1)},
+			false: {path("a", 0), path("a", "1")},
+		},
+		`$["a.b"].*`: {
+			true:  {path("a.b", "c"), path("a.b", 3)},
+			false: {path("a", "b", "c")},
+		},
+		"$..a..b": {
+			true:  {path("x", "a", "y", "z", "b")},
+			false: {path("b", "a")},
+		},
+	} {
+		steps, err := parseSelector(selector)
+		require.NoError(t, err, selector)
+
+		for expected, paths := range cases {
+			for _, p := range paths {
+				require.Equal(t, expected, matchSelector(steps, p), "%s %v", selector, p)
+			}
+		}
+	}
+}
+
+func BenchmarkRedactJSON(b *testing.B) {
+	document := []byte(`{"users":[` +
+		`{"name":"John","password":"a","tokens":["x","y"],"address":{"street":"s","city":"c"}},` +
+		`{"name":"Jane","password":"b","tokens":["z"],"address":{"street":"t","city":"d"}}]}`)
+	rules := []Rule{
+		PathRule("$.users[*].password", "-"),
+		PathRule("$..street", "mask"),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		RedactJSON(document, rules...)
+	}
+}
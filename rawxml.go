@@ -0,0 +1,240 @@
+package desensitivize
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+var xmlContentType = reflect.TypeOf([]xml.Token(nil))
+
+// RedactXML streams the XML document read from r to w, redacting it by rules.
+// Elements are selected by their local name, e.g. $.Envelope.Body.Password,
+// and attributes by their local name prefixed with @, e.g. $..Card['@number'].
+// The text of selected elements and the values of selected attributes are
+// redacted with the strategy of the rule, while the content of selected
+// elements which have child elements is dropped. Empty elements are written
+// with end tags. When RedactXML fails w may have received part of the
+// document.
+func RedactXML(r io.Reader, w io.Writer, rules ...Rule) error {
+	if err := checkRules(rules); err != nil {
+		return err
+	}
+
+	d := &xmlDocument{
+		r:     &redactor{},
+		dec:   xml.NewDecoder(r),
+		rules: rules,
+		w:     bufio.NewWriter(w),
+	}
+
+	if err := d.run(); err != nil {
+		return err
+	}
+
+	if d.r.err != nil {
+		return d.r.err
+	}
+
+	return d.w.Flush()
+}
+
+type xmlDocument struct {
+	r     *redactor
+	dec   *xml.Decoder
+	rules []Rule
+	w     *bufio.Writer
+	open  []xml.Name
+}
+
+func (d *xmlDocument) run() error {
+	for {
+		token, err := d.dec.RawToken()
+		if err == io.EOF {
+			if len(d.open) > 0 {
+				return fmt.Errorf("desensitivize: unexpected EOF in element %s", xmlName(d.open[len(d.open)-1]))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			err = d.start(token)
+		case xml.EndElement:
+			err = d.end(token)
+		case xml.CharData:
+			writeXMLText(d.w, d.r.redactString(string(token), d.rules), false)
+		default:
+			writeXMLToken(d.w, token)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *xmlDocument) start(token xml.StartElement) error {
+	d.r.enterField(token.Name.Local)
+	d.open = append(d.open, token.Name)
+
+	rule, selected := d.r.matchPath(d.rules)
+	d.writeStart(token)
+
+	if !selected {
+		return nil
+	}
+
+	err := d.redactContent(rule.strategy)
+	if err != nil {
+		return err
+	}
+
+	return d.end(xml.EndElement{Name: token.Name})
+}
+
+func (d *xmlDocument) end(token xml.EndElement) error {
+	if len(d.open) == 0 {
+		return fmt.Errorf("desensitivize: unexpected end element %s", xmlName(token.Name))
+	}
+
+	if open := d.open[len(d.open)-1]; open != token.Name {
+		return fmt.Errorf("desensitivize: element %s closed by %s", xmlName(open), xmlName(token.Name))
+	}
+
+	d.open = d.open[:len(d.open)-1]
+	d.r.leave()
+
+	d.w.WriteString("</")
+	d.w.WriteString(xmlName(token.Name))
+	d.w.WriteByte('>')
+	return nil
+}
+
+func (d *xmlDocument) writeStart(token xml.StartElement) {
+	d.w.WriteByte('<')
+	d.w.WriteString(xmlName(token.Name))
+
+	for _, attr := range token.Attr {
+		value := attr.Value
+
+		// Namespace declarations aren't data.
+		if attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+			d.r.enterField("@" + attr.Name.Local)
+			if rule, selected := d.r.matchPath(d.rules); selected {
+				value = d.r.redactValue(reflect.ValueOf(value), rule.strategy).String()
+			} else {
+				value = d.r.redactString(value, d.rules)
+			}
+			d.r.leave()
+		}
+
+		d.w.WriteByte(' ')
+		d.w.WriteString(xmlName(attr.Name))
+		d.w.WriteString(`="`)
+		writeXMLText(d.w, value, true)
+		d.w.WriteByte('"')
+	}
+
+	d.w.WriteByte('>')
+}
+
+// redactContent reads the content of the selected element up to its end
+// element, and writes the redacted text if it has no child elements.
+func (d *xmlDocument) redactContent(strategy string) error {
+	var (
+		text     strings.Builder
+		children bool
+	)
+
+	for depth := 0; ; {
+		token, err := d.dec.RawToken()
+		if err == io.EOF {
+			return errors.New("desensitivize: unexpected EOF in selected element")
+		}
+		if err != nil {
+			return err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			children = true
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				if children {
+					d.r.record(xmlContentType, strategy)
+					return nil
+				}
+
+				redacted := d.r.redactValue(reflect.ValueOf(text.String()), strategy)
+				writeXMLText(d.w, redacted.String(), false)
+				return nil
+			}
+			depth--
+		case xml.CharData:
+			text.Write(token)
+		}
+	}
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func writeXMLToken(w *bufio.Writer, token xml.Token) {
+	switch token := token.(type) {
+	case xml.Comment:
+		w.WriteString("<!--")
+		w.Write(token)
+		w.WriteString("-->")
+	case xml.ProcInst:
+		w.WriteString("<?")
+		w.WriteString(token.Target)
+		if len(token.Inst) > 0 {
+			w.WriteByte(' ')
+			w.Write(token.Inst)
+		}
+		w.WriteString("?>")
+	case xml.Directive:
+		w.WriteString("<!")
+		w.Write(token)
+		w.WriteByte('>')
+	}
+}
+
+// writeXMLText escapes s as character data, or as an attribute value. Unlike
+// xml.EscapeText it keeps the line breaks of character data.
+func writeXMLText(w *bufio.Writer, s string, attr bool) {
+	for _, c := range s {
+		switch {
+		case c == '&':
+			w.WriteString("&amp;")
+		case c == '<':
+			w.WriteString("&lt;")
+		case c == '>':
+			w.WriteString("&gt;")
+		case c == '"' && attr:
+			w.WriteString("&quot;")
+		case c == '\n' && attr:
+			w.WriteString("&#xA;")
+		case c == '\r':
+			w.WriteString("&#xD;")
+		case c == '\t' && attr:
+			w.WriteString("&#x9;")
+		default:
+			w.WriteRune(c)
+		}
+	}
+}
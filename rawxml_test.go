@@ -0,0 +1,102 @@
+package desensitivize
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactXML(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- login -->
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <Login user="john" token="abc&amp;def">
+      <Password>hunter2</Password>
+      <Card number="4111111111111111"><Holder>John</Holder></Card>
+      <Notes>call 555-0100 &lt;now&gt;</Notes>
+      <Session><Token>abc</Token><Token>def</Token></Session>
+      <Empty/>
+    </Login>
+  </soap:Body>
+</soap:Envelope>`
+
+	var out bytes.Buffer
+	err := RedactXML(strings.NewReader(document), &out,
+		PathRule("$.Envelope.Body.Login.Password", "-"),
+		PathRule("$..Card['@number']", "mask:last=4"),
+		PathRule("$..@token", "placeholder"),
+		PathRule("$..Session", "-"),
+		PathRule("$..Empty", "mask"),
+		ValueRule(regexp.MustCompile(`\d{3}-\d{4}`), "mask"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!-- login -->
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <Login user="john" token="[REDACTED string len=7]">
+      <Password></Password>
+      <Card number="************1111"><Holder>John</Holder></Card>
+      <Notes>call ******** &lt;now&gt;</Notes>
+      <Session></Session>
+      <Empty></Empty>
+    </Login>
+  </soap:Body>
+</soap:Envelope>`, out.String())
+}
+
+func TestRedactXMLKeyRules(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	var out bytes.Buffer
+	err := RedactXML(strings.NewReader(`<a Secret="x&#xA;y"><password>p&amp;q</password><b>password</b></a>`), &out,
+		KeyRule("password", "mask"),
+		KeyRule("@secret", "mask:last=1"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `<a Secret="**y"><password>***</password><b>password</b></a>`, out.String())
+}
+
+func TestRedactXMLReport(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	var events []Event
+	redactHooks = append(redactHooks, func(event Event) {
+		events = append(events, event)
+	})
+	defer func() { redactHooks = nil }()
+
+	err := RedactXML(strings.NewReader(`<a><b c="1">x</b><b><d/></b></a>`), &bytes.Buffer{},
+		PathRule("$.a.b", "-"),
+		PathRule("$.a.b.@c", "-"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []Event{
+		{Path: "a.b.@c", Strategy: "-", Type: "string"},
+		{Path: "a.b", Strategy: "-", Type: "string"},
+		{Path: "a.b", Strategy: "-", Type: "[]xml.Token"},
+	}, events)
+}
+
+func TestRedactXMLErrors(t *testing.T) {
+	for document, expected := range map[string]string{
+		`<a><b></a>`:   "desensitivize: element b closed by a",
+		`<a>`:          "desensitivize: unexpected EOF in element a",
+		`</a>`:         "desensitivize: unexpected end element a",
+		`<a><secret>x`: "desensitivize: unexpected EOF in selected element",
+		`<a x="1></a>`: "XML syntax error on line 1: unescaped < inside quoted string",
+	} {
+		err := RedactXML(strings.NewReader(document), &bytes.Buffer{}, PathRule("$..secret", "-"))
+		require.EqualError(t, err, expected, document)
+	}
+
+	err := RedactXML(strings.NewReader(`<a/>`), &bytes.Buffer{}, PathRule("a", "-"))
+	require.EqualError(t, err, `desensitivize: selector "a" doesn't start with $`)
+}
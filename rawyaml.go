@@ -0,0 +1,349 @@
+package desensitivize
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactYAML redacts the YAML documents in data by rules and encodes them
+// again with an indentation of two spaces. Comments, the order of keys,
+// anchors and the styles of scalars are kept. Aliases and merge keys are
+// followed: an alias whose value path rules redact is replaced with a redacted
+// copy, and redacted copies of merged entries are appended to the mapping
+// merging them, overriding them. Like TryRedact it fails when the redaction
+// does.
+func RedactYAML(data []byte, rules ...Rule) ([]byte, error) {
+	if err := checkRules(rules); err != nil {
+		return nil, err
+	}
+
+	var (
+		out bytes.Buffer
+		r   = &redactor{}
+	)
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		r.redactYAML(&node, rules)
+		if err := enc.Encode(&node); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// RedactYAMLNode redacts the tree of node in place by rules. Mapping keys are
+// path keys and sequence items path indexes, so $.db.password selects the
+// password in the db mapping of a document.
+func RedactYAMLNode(node *yaml.Node, rules ...Rule) error {
+	if err := checkRules(rules); err != nil {
+		return err
+	}
+
+	r := &redactor{}
+	r.redactYAML(node, rules)
+	return r.err
+}
+
+func (r *redactor) redactYAML(node *yaml.Node, rules []Rule) {
+	if node.Kind == yaml.DocumentNode {
+		for _, content := range node.Content {
+			r.redactYAML(content, rules)
+		}
+		return
+	}
+
+	if rule, matched := r.matchPath(rules); matched {
+		r.redactYAMLNode(node, rule.strategy)
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		r.redactYAMLMapping(node, node, rules)
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			r.enterIndex(i)
+			r.redactYAML(item, rules)
+			r.leave()
+		}
+	case yaml.AliasNode:
+		if redacted, changed := r.redactYAMLAlias(node.Alias, rules); changed {
+			redacted.HeadComment = node.HeadComment
+			redacted.LineComment = node.LineComment
+			redacted.FootComment = node.FootComment
+			*node = *redacted
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!str" {
+			node.Value = r.redactString(node.Value, rules)
+		}
+	}
+}
+
+// redactYAMLMapping redacts the entries of mapping as entries of parent,
+// which differ for mappings merged inline into parent with a << key.
+func (r *redactor) redactYAMLMapping(mapping, parent *yaml.Node, rules []Rule) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if isYAMLMergeKey(key) {
+			// Written back without the tag, encoding it would add !!merge.
+			key.Tag = ""
+			r.redactYAMLMerge(value, parent, rules)
+			continue
+		}
+
+		alias := value.Kind == yaml.AliasNode
+
+		r.enterField(key.Value)
+		r.redactYAML(value, rules)
+		r.leave()
+
+		// The comment of an alias replaced with a block stays on its line.
+		if alias && (value.Kind == yaml.MappingNode || value.Kind == yaml.SequenceNode) && key.LineComment == "" {
+			key.LineComment, value.LineComment = value.LineComment, ""
+		}
+	}
+}
+
+// redactYAMLAlias redacts a copy of a node defined elsewhere, such as the
+// anchored node an alias refers to, as if it was written out at the current
+// path. The node itself was redacted where it's defined already, so only path
+// rules, which depend on where it shows up, are applied. The copy is returned
+// if they changed anything.
+func (r *redactor) redactYAMLAlias(anchored *yaml.Node, rules []Rule) (*yaml.Node, bool) {
+	if anchored == nil {
+		return nil, false
+	}
+
+	var pathRules []Rule
+	for _, rule := range rules {
+		if rule.kind == rulePath {
+			pathRules = append(pathRules, rule)
+		}
+	}
+
+	if len(pathRules) == 0 {
+		return nil, false
+	}
+
+	redacted := copyYAMLNode(anchored)
+	r.redactYAML(redacted, pathRules)
+
+	return redacted, !equalYAMLNodes(anchored, redacted)
+}
+
+// redactYAMLMerge redacts the mappings merged into parent by a << key. Inline
+// mappings are redacted in place. The entries of aliased ones which path
+// rules redact at parent are overridden by redacted copies appended to
+// parent, leaving the anchored mappings and the merge as they are.
+func (r *redactor) redactYAMLMerge(value, parent *yaml.Node, rules []Rule) {
+	sources := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		sources = value.Content
+	}
+
+	defined := map[string]bool{}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if !isYAMLMergeKey(parent.Content[i]) {
+			defined[parent.Content[i].Value] = true
+		}
+	}
+
+	for _, source := range sources {
+		switch {
+		case source.Kind == yaml.MappingNode:
+			r.redactYAMLMapping(source, parent, rules)
+		case source.Kind == yaml.AliasNode && source.Alias != nil && source.Alias.Kind == yaml.MappingNode:
+			for _, entry := range mergedYAMLEntries(source.Alias) {
+				if defined[entry[0].Value] {
+					continue
+				}
+				defined[entry[0].Value] = true
+
+				r.enterField(entry[0].Value)
+				redacted, changed := r.redactYAMLAlias(entry[1], rules)
+				r.leave()
+
+				if changed {
+					key := copyYAMLNode(entry[0])
+					parent.Content = append(parent.Content, key, redacted)
+				}
+			}
+		}
+	}
+}
+
+// mergedYAMLEntries lists the key and value pairs of mapping including the
+// ones it merges, with the first of duplicate keys taking precedence.
+func mergedYAMLEntries(mapping *yaml.Node) [][2]*yaml.Node {
+	var (
+		entries [][2]*yaml.Node
+		merges  []*yaml.Node
+		seen    = map[string]bool{}
+	)
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if isYAMLMergeKey(key) {
+			merges = append(merges, value)
+			continue
+		}
+
+		seen[key.Value] = true
+		entries = append(entries, [2]*yaml.Node{key, value})
+	}
+
+	for _, merge := range merges {
+		sources := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+
+		for _, source := range sources {
+			if source.Kind == yaml.AliasNode {
+				source = source.Alias
+			}
+			if source == nil || source.Kind != yaml.MappingNode {
+				continue
+			}
+
+			for _, entry := range mergedYAMLEntries(source) {
+				if !seen[entry[0].Value] {
+					seen[entry[0].Value] = true
+					entries = append(entries, entry)
+				}
+			}
+		}
+	}
+
+	return entries
+}
+
+func isYAMLMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!merge"
+}
+
+// copyYAMLNode copies node deeply without its anchor, since anchors may only
+// be defined once. Anchored nodes inside it are referred to by aliases.
+func copyYAMLNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Anchor = ""
+
+	if node.Content != nil {
+		copied.Content = make([]*yaml.Node, len(node.Content))
+		for i, content := range node.Content {
+			if content.Anchor != "" {
+				copied.Content[i] = &yaml.Node{
+					Kind:  yaml.AliasNode,
+					Value: content.Anchor,
+					Alias: content,
+				}
+				continue
+			}
+
+			copied.Content[i] = copyYAMLNode(content)
+		}
+	}
+
+	return &copied
+}
+
+// equalYAMLNodes tells whether the copy b of a is still the same.
+func equalYAMLNodes(a, b *yaml.Node) bool {
+	if b.Kind == yaml.AliasNode && b.Alias == a {
+		return true
+	}
+
+	if a.Kind != b.Kind || a.Tag != b.Tag || a.Value != b.Value || a.Alias != b.Alias || len(a.Content) != len(b.Content) {
+		return false
+	}
+
+	for i := range a.Content {
+		if !equalYAMLNodes(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// redactYAMLNode redacts a selected node. Strings are redacted with the
+// strategy like string fields, other values become null. Aliases are
+// replaced, so the anchored value they refer to isn't disclosed.
+func (r *redactor) redactYAMLNode(node *yaml.Node, strategy string) {
+	target := node
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		target = node.Alias
+	}
+
+	if target.Kind == yaml.ScalarNode && target.ShortTag() == "!!str" {
+		redacted := r.redactValue(reflect.ValueOf(target.Value), strategy)
+		*node = yaml.Node{
+			Kind:        yaml.ScalarNode,
+			Style:       target.Style,
+			Tag:         "!!str",
+			Value:       redacted.String(),
+			Anchor:      node.Anchor,
+			HeadComment: node.HeadComment,
+			LineComment: node.LineComment,
+			FootComment: node.FootComment,
+			Line:        node.Line,
+			Column:      node.Column,
+		}
+		return
+	}
+
+	var valType reflect.Type
+	switch target.Kind {
+	case yaml.MappingNode:
+		valType = jsonObjectType
+	case yaml.SequenceNode:
+		valType = jsonArrayType
+	default:
+		var decoded any
+		if err := target.Decode(&decoded); err == nil && decoded != nil {
+			valType = reflect.TypeOf(decoded)
+		} else {
+			valType = jsonAnyType
+		}
+	}
+	r.record(valType, strategy)
+
+	*node = yaml.Node{
+		Kind:        yaml.ScalarNode,
+		Tag:         "!!null",
+		Value:       "null",
+		Anchor:      node.Anchor,
+		HeadComment: node.HeadComment,
+		LineComment: node.LineComment,
+		FootComment: node.FootComment,
+		Line:        node.Line,
+		Column:      node.Column,
+	}
+}
@@ -0,0 +1,176 @@
+package desensitivize
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRedactYAML(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	document := `# service config
+service:
+  name: billing # the name
+  port: 8080
+db:
+  host: db.internal
+  password: "hunter2"
+  pool: &pool
+    size: 10
+    token: abc
+replica:
+  pool: *pool
+  password: &pw secret
+  backup_password: *pw
+api_keys:
+  - 'k-123'
+  - k-456
+notes: |
+  call 555-0100
+---
+client_secret: x
+`
+
+	redacted, err := RedactYAML([]byte(document),
+		KeyRule("*password", "-"),
+		KeyRule("*_secret", "placeholder"),
+		PathRule("$.api_keys[*]", "mask:last=2"),
+		PathRule("$.db.pool", "-"),
+		PathRule("$.service.port", "-"),
+		ValueRule(regexp.MustCompile(`\d{3}-\d{4}`), "mask"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `# service config
+service:
+  name: billing # the name
+  port: null
+db:
+  host: db.internal
+  password: ""
+  pool: &pool null
+replica:
+  pool: *pool
+  password: &pw ""
+  backup_password: ""
+api_keys:
+  - '***23'
+  - '***56'
+notes: |
+  call ********
+---
+client_secret: '[REDACTED string len=1]'
+`, string(redacted))
+}
+
+func TestRedactYAMLAliasesAndMerges(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	document := `def: &def
+  user: app
+  password: hunter2
+  db: &db
+    token: abc
+prod:
+  <<: *def
+  host: prod
+stage: *def # shared
+dev:
+  <<: [*def, {port: 1}]
+  password: dev
+list:
+  - *db
+`
+
+	redacted, err := RedactYAML([]byte(document),
+		PathRule("$.prod.password", "-"),
+		PathRule("$.stage.password", "-"),
+		PathRule("$.dev.password", "-"),
+		PathRule("$.dev.port", "-"),
+		PathRule("$.list[0].token", "mask"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `def: &def
+  user: app
+  password: hunter2
+  db: &db
+    token: abc
+prod:
+  <<: *def
+  host: prod
+  password: ""
+stage: # shared
+  user: app
+  password: ""
+  db: *db
+dev:
+  <<: [*def, {port: null}]
+  password: ""
+list:
+  - token: '***'
+`, string(redacted))
+
+	var decoded struct {
+		Prod  map[string]any `yaml:"prod"`
+		Stage map[string]any `yaml:"stage"`
+	}
+	require.NoError(t, yaml.Unmarshal(redacted, &decoded))
+	require.Equal(t, "", decoded.Prod["password"])
+	require.Equal(t, "app", decoded.Prod["user"])
+	require.Equal(t, "", decoded.Stage["password"])
+
+	// Nothing is copied when the rules don't reach into aliases.
+	unchanged, err := RedactYAML([]byte(document), PathRule("$.def.user", "-"))
+	require.NoError(t, err)
+	require.Contains(t, string(unchanged), "<<: *def\n")
+	require.Contains(t, string(unchanged), "stage: *def # shared\n")
+}
+
+func TestRedactYAMLNode(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	var events []Event
+	redactHooks = append(redactHooks, func(event Event) {
+		events = append(events, event)
+	})
+	defer func() { redactHooks = nil }()
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("a: [1, {b: x}]\nc: true\nd: {e: 1}\n"), &node))
+	require.NoError(t, RedactYAMLNode(&node,
+		PathRule("$.a[0]", "-"),
+		PathRule("$.a[1].b", "-"),
+		PathRule("$.c", "-"),
+		PathRule("$.d", "-"),
+	))
+
+	var decoded map[string]any
+	require.NoError(t, node.Decode(&decoded))
+	require.Equal(t, map[string]any{
+		"a": []any{nil, map[string]any{"b": ""}},
+		"c": nil,
+		"d": nil,
+	}, decoded)
+	require.Equal(t, []Event{
+		{Path: "a[0]", Strategy: "-", Type: "int"},
+		{Path: "a[1].b", Strategy: "-", Type: "string"},
+		{Path: "c", Strategy: "-", Type: "bool"},
+		{Path: "d", Strategy: "-", Type: "map[string]interface {}"},
+	}, events)
+}
+
+func TestRedactYAMLErrors(t *testing.T) {
+	_, err := RedactYAML([]byte("a: b"), PathRule("a", "-"))
+	require.EqualError(t, err, `desensitivize: selector "a" doesn't start with $`)
+
+	_, err = RedactYAML([]byte("a: [b"), PathRule("$.a", "-"))
+	require.Error(t, err)
+
+	_, err = RedactYAML([]byte("a: b"), PathRule("$.a", "trim|unknown"))
+	require.Error(t, err)
+
+	require.Error(t, RedactYAMLNode(&yaml.Node{}, PathRule("$[", "-")))
+}
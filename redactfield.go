@@ -0,0 +1,29 @@
+package desensitivize
+
+import "reflect"
+
+// RedactField redacts val like a field tagged with the given strategy, e.g.
+// "-" or "trim|mask:last=4". It's called by the methods desensitivize-gen
+// generates and, like Redact, redacts to the zero value when the strategy
+// fails.
+func RedactField[T any](val T, strategy string) T {
+	valType := reflect.TypeOf((*T)(nil)).Elem()
+
+	// Strategies redacting to the zero value don't need reflection.
+	if _, custom := customRedacts[valType]; !custom && len(redactHooks) == 0 && strategy != placeholderStrategy {
+		if pipeline, err := pipelineFor(strategy); err == nil && pipeline == nil {
+			var zero T
+			return zero
+		}
+	}
+
+	return redactFieldValue(val, strategy)
+}
+
+func redactFieldValue[T any](val T, strategy string) T {
+	r := &redactor{tag: strategy}
+
+	var result T
+	reflect.ValueOf(&result).Elem().Set(r.redactValue(reflect.ValueOf(&val).Elem(), strategy))
+	return result
+}
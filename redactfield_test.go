@@ -0,0 +1,27 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactField(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	defer func() { customRedacts = map[reflect.Type]redactMeta{} }()
+
+	type Name string
+
+	require.Equal(t, "", RedactField("secret", "-"))
+	require.Equal(t, Name("******cret"), RedactField(Name("confidcret"), "mask:last=4"))
+	require.Equal(t, "[REDACTED string len=6]", RedactField("secret", "placeholder"))
+	require.Nil(t, RedactField(&struct{ A string }{A: "a"}, "-"))
+
+	SetCustomRedact("email", "redacted@example.com")
+	require.Equal(t, "redacted@example.com", RedactField("john@example.com", "email"))
+	require.Equal(t, "", RedactField("john@example.com", "-"))
+
+	SetDefaultRedact("REDACTED")
+	require.Equal(t, "REDACTED", RedactField("john@example.com", "-"))
+}
@@ -0,0 +1,60 @@
+package desensitivize
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Report lists everything RedactWithReport removed.
+type Report struct {
+	Redactions []Redaction
+	Stats
+}
+
+// Redaction is a single redacted value.
+type Redaction struct {
+	// Path locates the value, e.g. Users[3].Address.Street or Meta["token"].
+	Path string
+	// Tag is the whole tag value of the field, Strategy the part of it
+	// deciding how the value was redacted.
+	Tag      string
+	Strategy string
+	Type     string
+}
+
+// RedactWithReport works like Redact and lists every redacted value ordered
+// by path.
+func RedactWithReport[T any](obj T) (T, Report) {
+	r := &redactor{recording: true}
+	redacted := redact(r, obj)
+
+	sort.SliceStable(r.redactions, func(i, j int) bool {
+		return r.redactions[i].Path < r.redactions[j].Path
+	})
+
+	return redacted, Report{
+		Redactions: r.redactions,
+		Stats:      r.stats,
+	}
+}
+
+func (r *redactor) record(valType reflect.Type, strategy string) {
+	if r.probing || !r.recording && len(redactHooks) == 0 {
+		return
+	}
+
+	redaction := Redaction{
+		Path:     r.currentPath(),
+		Tag:      r.tag,
+		Strategy: strategy,
+		Type:     valType.String(),
+	}
+
+	if r.recording {
+		r.redactions = append(r.redactions, redaction)
+	}
+
+	for _, hook := range redactHooks {
+		hook(redaction)
+	}
+}
@@ -0,0 +1,66 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactWithReport(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type (
+		Address struct {
+			Street string `sensitive:"-"`
+			City   string
+		}
+
+		MapKey struct {
+			ID    string `sensitive:"-"`
+			Scope string
+		}
+
+		User struct {
+			Name    string
+			Address *Address
+			Tokens  []string `sensitive:"each,mask"`
+		}
+
+		Response struct {
+			Users []User
+			Meta  map[string]string `sensitive:"entries=token"`
+			Keys  map[MapKey]int
+			Arr   [1]Address
+		}
+	)
+
+	obj := Response{
+		Users: []User{
+			{
+				Name:    "John",
+				Address: &Address{Street: "street", City: "city"},
+				Tokens:  []string{"abc"},
+			},
+		},
+		Meta: map[string]string{
+			"token": "token",
+			"trace": "trace",
+		},
+		Keys: map[MapKey]int{
+			{ID: "id", Scope: "scope"}: 1,
+		},
+		Arr: [1]Address{{Street: "street"}},
+	}
+
+	redacted, report := RedactWithReport(obj)
+	require.Equal(t, Redact(obj), redacted)
+	require.Equal(t, []Redaction{
+		{Path: "Arr[0].Street", Tag: "-", Strategy: "-", Type: "string"},
+		{Path: "Keys{key}.ID", Tag: "-", Strategy: "-", Type: "string"},
+		{Path: `Meta["token"]`, Tag: "entries=token", Strategy: "", Type: "string"},
+		{Path: "Users[0].Address.Street", Tag: "-", Strategy: "-", Type: "string"},
+		{Path: "Users[0].Tokens[0]", Tag: "each,mask", Strategy: "mask", Type: "string"},
+	}, report.Redactions)
+	require.Equal(t, Stats{}, report.Stats)
+}
@@ -0,0 +1,304 @@
+package desensitivize
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ruleKind int
+
+const (
+	rulePath ruleKind = iota
+	ruleValue
+	ruleKey
+)
+
+var keyRules []Rule
+
+// Rule selects values to redact in data without struct tags, such as raw
+// JSON documents or maps decoded from them.
+type Rule struct {
+	kind     ruleKind
+	selector string
+	steps    []selectorStep
+	pattern  *regexp.Regexp
+	strategy string
+	err      error
+}
+
+// PathRule redacts the values selected by a JSONPath-like selector with the
+// strategy a `sensitive` tag would have. Selectors start with $ and consist of
+// keys (.user or ['user']), indexes ([0]), wildcards (.* or [*]) and
+// descendants (..token), e.g. $.user.password, $..token or $.items[*].card.
+func PathRule(selector, strategy string) Rule {
+	steps, err := parseSelector(selector)
+	return Rule{
+		kind:     rulePath,
+		selector: selector,
+		steps:    steps,
+		strategy: strategy,
+		err:      err,
+	}
+}
+
+// ValueRule redacts every match of pattern inside string values with the
+// strategy, wherever the strings are.
+func ValueRule(pattern *regexp.Regexp, strategy string) Rule {
+	return Rule{
+		kind:     ruleValue,
+		pattern:  pattern,
+		strategy: strategy,
+	}
+}
+
+// KeyRule redacts the values of object and map entries whose key matches
+// pattern, at any depth. The pattern is case-insensitive and may contain *
+// for any number of characters and ? for a single one, e.g. "password" or
+// "*_secret".
+func KeyRule(pattern, strategy string) Rule {
+	return Rule{
+		kind:     ruleKey,
+		selector: pattern,
+		pattern:  globPattern(pattern),
+		strategy: strategy,
+	}
+}
+
+// globPattern compiles a case-insensitive pattern with * and ? wildcards
+// matching whole names.
+func globPattern(pattern string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+
+	return regexp.MustCompile("(?is)^" + expr + "$")
+}
+
+// SetKeyRules makes Redact apply the rules to maps with string keys, e.g.
+// map[string]any trees decoded from JSON, including maps held by interfaces.
+// It panics if a rule wasn't created by KeyRule.
+func SetKeyRules(rules ...Rule) {
+	for _, rule := range rules {
+		if rule.kind != ruleKey {
+			panic("desensitivize: SetKeyRules accepts key rules only")
+		}
+	}
+
+	keyRules = append([]Rule(nil), rules...)
+	invalidatePlans()
+}
+
+// HasKeyRules tells whether SetKeyRules set any rules. Methods generated by
+// desensitivize-gen fall back to Redact for maps with string keys then.
+func HasKeyRules() bool {
+	return len(keyRules) > 0
+}
+
+// keyRulePatterns lists the patterns of the key rules.
+func keyRulePatterns() []string {
+	patterns := make([]string, len(keyRules))
+	for i, rule := range keyRules {
+		patterns[i] = rule.selector
+	}
+
+	return patterns
+}
+
+// keyRulesApply tells whether the key rules apply to the entries of maps of
+// typ.
+func keyRulesApply(typ reflect.Type) bool {
+	return len(keyRules) > 0 && typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String
+}
+
+// matchKey returns the first key rule matching key.
+func matchKey(rules []Rule, key string) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.kind == ruleKey && rule.pattern.MatchString(key) {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+func checkRules(rules []Rule) error {
+	for _, rule := range rules {
+		if rule.err != nil {
+			return rule.err
+		}
+	}
+
+	return nil
+}
+
+type selectorStep struct {
+	descendant bool
+	wildcard   bool
+	isIndex    bool
+	key        string
+	index      int
+}
+
+func parseSelector(selector string) ([]selectorStep, error) {
+	if !strings.HasPrefix(selector, "$") {
+		return nil, fmt.Errorf("desensitivize: selector %q doesn't start with $", selector)
+	}
+
+	var steps []selectorStep
+	for rest := selector[1:]; rest != ""; {
+		var step selectorStep
+
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			step.descendant = true
+			rest = rest[2:]
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] != '[':
+			return nil, fmt.Errorf("desensitivize: unexpected %q in selector %q", rest[0], selector)
+		}
+
+		if strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if len(rest) > 1 && (rest[1] == '\'' || rest[1] == '"') {
+				closing := strings.IndexByte(rest[2:], rest[1])
+				if closing < 0 || !strings.HasPrefix(rest[2+closing+1:], "]") {
+					return nil, fmt.Errorf("desensitivize: unterminated key in selector %q", selector)
+				}
+				end = 2 + closing + 1
+			}
+
+			if end < 0 {
+				return nil, fmt.Errorf("desensitivize: missing ] in selector %q", selector)
+			}
+
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			switch {
+			case inner == "*":
+				step.wildcard = true
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"'):
+				step.key = inner[1 : len(inner)-1]
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("desensitivize: invalid index %q in selector %q", inner, selector)
+				}
+				step.isIndex = true
+				step.index = index
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			name := rest[:end]
+			rest = rest[end:]
+
+			switch name {
+			case "":
+				return nil, fmt.Errorf("desensitivize: empty key in selector %q", selector)
+			case "*":
+				step.wildcard = true
+			default:
+				step.key = name
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+func (s selectorStep) matches(segment pathSegment) bool {
+	switch {
+	case s.wildcard:
+		return segment.kind == segmentField || segment.kind == segmentIndex || segment.kind == segmentKey
+	case s.isIndex:
+		return segment.kind == segmentIndex && segment.index == s.index
+	case segment.kind == segmentField:
+		return segment.field == s.key
+	case segment.kind == segmentKey:
+		return segment.key.Kind() == reflect.String && segment.key.String() == s.key
+	}
+
+	return false
+}
+
+func matchSelector(steps []selectorStep, path []pathSegment) bool {
+	if len(steps) == 0 {
+		return len(path) == 0
+	}
+
+	step := steps[0]
+	if !step.descendant {
+		return len(path) > 0 && step.matches(path[0]) && matchSelector(steps[1:], path[1:])
+	}
+
+	for i := range path {
+		if step.matches(path[i]) && matchSelector(steps[1:], path[i+1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPath returns the first path or key rule selecting the current path.
+func (r *redactor) matchPath(rules []Rule) (Rule, bool) {
+	path := r.path[r.root:]
+
+	for _, rule := range rules {
+		switch rule.kind {
+		case rulePath:
+			if matchSelector(rule.steps, path) {
+				return rule, true
+			}
+		case ruleKey:
+			if len(path) == 0 {
+				continue
+			}
+
+			last := path[len(path)-1]
+			if last.kind == segmentField && rule.pattern.MatchString(last.field) {
+				return rule, true
+			}
+		}
+	}
+
+	return Rule{}, false
+}
+
+// redactDynamic redacts what an interface holds rather than the interface,
+// so strategies apply to the strings inside map[string]any.
+func (r *redactor) redactDynamic(obj reflect.Value, strategy string) reflect.Value {
+	if obj.Kind() != reflect.Interface || obj.IsNil() {
+		return r.redactValue(obj, strategy)
+	}
+
+	redacted := reflect.New(obj.Type()).Elem()
+	redacted.Set(r.redactValue(obj.Elem(), strategy))
+	return redacted
+}
+
+// redactString applies the value rules to s.
+func (r *redactor) redactString(s string, rules []Rule) string {
+	for _, rule := range rules {
+		if rule.kind != ruleValue {
+			continue
+		}
+
+		strategy := rule.strategy
+		s = rule.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			return r.redactValue(reflect.ValueOf(match), strategy).String()
+		})
+	}
+
+	return s
+}
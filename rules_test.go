@@ -0,0 +1,129 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type keyRulesPayload struct {
+	Name string
+	Data map[string]any
+}
+
+func newKeyRulesPayload() keyRulesPayload {
+	return keyRulesPayload{
+		Name: "payload",
+		Data: map[string]any{
+			"user":     "john",
+			"PASSWORD": "hunter2",
+			"nested": map[string]any{
+				"client_secret": "abc",
+				"items": []any{
+					map[string]any{"Api_Secret": "def", "count": 2},
+					"password",
+				},
+			},
+			"db_secret": map[string]any{"host": "db"},
+			"port":      5432,
+		},
+	}
+}
+
+func TestKeyRules(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	SetKeyRules(KeyRule("password", "mask"), KeyRule("*_secret", "-"))
+	defer SetKeyRules()
+
+	expected := keyRulesPayload{
+		Name: "payload",
+		Data: map[string]any{
+			"user":     "john",
+			"PASSWORD": "*******",
+			"nested": map[string]any{
+				"client_secret": "",
+				"items": []any{
+					map[string]any{"Api_Secret": "", "count": 2},
+					"password",
+				},
+			},
+			"db_secret": map[string]any(nil),
+			"port":      5432,
+		},
+	}
+
+	for _, mode := range []CopyMode{CopyDeep, CopyOnWrite} {
+		SetCopyMode(mode)
+
+		payload := newKeyRulesPayload()
+		require.Equal(t, expected, Redact(payload))
+		require.Equal(t, newKeyRulesPayload(), payload)
+	}
+	SetCopyMode(CopyDeep)
+
+	payload := newKeyRulesPayload()
+	require.NoError(t, RedactInPlace(&payload))
+	require.Equal(t, expected, payload)
+
+	redacted := Redact(map[string]string{"Password": "x", "name": "y"})
+	require.Equal(t, map[string]string{"Password": "*", "name": "y"}, redacted)
+}
+
+func TestKeyRulesExplain(t *testing.T) {
+	require.True(t, Explain[keyRulesPayload]().Empty())
+
+	SetKeyRules(KeyRule("password", "mask"), KeyRule("*_secret", "-"))
+	defer SetKeyRules()
+
+	require.Equal(t, `desensitivize.keyRulesPayload
+  Data map[string]interface {}: key-rules password|*_secret
+`, Explain[keyRulesPayload]().String())
+
+	require.Equal(t, `# desensitivize manifest of desensitivize.keyRulesPayload
+Name string plain
+Data map[string]interface {} key-rules password|*_secret
+Data[*] interface {} dynamic
+`, Manifest[keyRulesPayload]())
+}
+
+func TestKeyRulesMarshalJSON(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	SetKeyRules(KeyRule("pass?ord", "-"))
+	defer SetKeyRules()
+
+	data, err := MarshalJSON(map[string]any{"passWord": "x", "other": []any{map[string]any{"password": 1}}})
+	require.NoError(t, err)
+	require.Equal(t, `{"other":[{"password":0}],"passWord":""}`, string(data))
+}
+
+func TestKeyRulesOnlyMatchWholeKeys(t *testing.T) {
+	rule := KeyRule("*_secret", "-")
+	for key, matched := range map[string]bool{
+		"db_secret":     true,
+		"DB_SECRET":     true,
+		"_secret":       true,
+		"secret":        false,
+		"db_secrets":    false,
+		"db.secret":     false,
+		"a_secret\nfoo": false,
+	} {
+		_, ok := matchKey([]Rule{rule}, key)
+		require.Equal(t, matched, ok, key)
+	}
+
+	require.Panics(t, func() { SetKeyRules(PathRule("$.a", "-")) })
+}
+
+func TestRedactJSONKeyRules(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	redacted, err := RedactJSON([]byte(`{"Password":"abc","a":[{"client_secret":{"x":1}}],"b":"password"}`),
+		KeyRule("password", "mask"),
+		KeyRule("*_secret", "-"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `{"Password":"***","a":[{"client_secret":null}],"b":"password"}`, string(redacted))
+}
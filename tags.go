@@ -0,0 +1,162 @@
+package desensitivize
+
+import (
+	"reflect"
+	"strings"
+)
+
+const anyTagValue = "*"
+
+type tagScope int
+
+const (
+	scopeWhole tagScope = iota
+	scopeKeys
+	scopeValues
+	scopeEntries
+	scopeEach
+)
+
+type tagSpec struct {
+	strategy  string
+	scope     tagScope
+	entries   []string
+	condition string
+
+	// encoding is set for strings and byte slices holding a document whose
+	// values selected by rules are redacted.
+	encoding string
+	rules    []Rule
+}
+
+var (
+	tagNames     []string
+	tagValueMaps map[string]map[string]string
+)
+
+func init() {
+	tagNames = []string{"sensitive"}
+	tagValueMaps = map[string]map[string]string{}
+}
+
+// SetTagNames replaces the struct tag names marking a field as sensitive.
+// When a field has several of them the first name in the list wins.
+func SetTagNames(names ...string) {
+	tagNames = append([]string(nil), names...)
+	invalidatePlans()
+}
+
+// MapTagValue translates the value of a foreign tag to a strategy, i.e. the
+// value a `sensitive` tag would have. The value "*" matches any value
+// without its own mapping. Once a tag name has mappings, values without
+// one don't mark the field as sensitive, so `log:"-"` can be mapped while
+// `log:"name"` is left alone.
+func MapTagValue(tagName, value, strategy string) {
+	mapping, exist := tagValueMaps[tagName]
+	if !exist {
+		mapping = map[string]string{}
+	}
+
+	mapping[value] = strategy
+	tagValueMaps[tagName] = mapping
+	invalidatePlans()
+}
+
+func lookupTag(structType reflect.Type, index int) (string, bool) {
+	if tag, exist := lookupPolicy(structType, index); exist {
+		return tag, true
+	}
+
+	fieldTag := structType.Field(index).Tag
+	for _, name := range tagNames {
+		value, exist := fieldTag.Lookup(name)
+		if !exist {
+			continue
+		}
+
+		mapping, mapped := tagValueMaps[name]
+		if !mapped {
+			return value, true
+		}
+
+		if strategy, exist := mapping[value]; exist {
+			return strategy, true
+		}
+
+		if strategy, exist := mapping[anyTagValue]; exist {
+			return strategy, true
+		}
+	}
+
+	return "", false
+}
+
+func parseTag(tag string) tagSpec {
+	var (
+		spec      tagSpec
+		strategy  []string
+		selectors []string
+	)
+
+	options := strings.Split(tag, ",")
+	for i, option := range options {
+		if strings.HasPrefix(option, "if=") {
+			spec.condition = strings.TrimPrefix(strings.Join(options[i:], ","), "if=")
+			break
+		}
+
+		// The selectors of an embedded document take the rest of the tag.
+		if spec.encoding != "" {
+			selectors = append(selectors, option)
+			continue
+		}
+
+		switch {
+		case option == "keys":
+			spec.scope = scopeKeys
+		case option == "values":
+			spec.scope = scopeValues
+		case option == "each":
+			spec.scope = scopeEach
+		case strings.HasPrefix(option, "entries="):
+			spec.scope = scopeEntries
+			spec.entries = strings.Split(strings.TrimPrefix(option, "entries="), "|")
+		case strings.HasPrefix(option, encodingJSON+":"), strings.HasPrefix(option, encodingBase64JSON+":"):
+			var selector string
+			spec.encoding, selector, _ = strings.Cut(option, ":")
+			selectors = append(selectors, selector)
+		default:
+			strategy = append(strategy, option)
+		}
+	}
+
+	spec.strategy = strings.Join(strategy, ",")
+	for _, selector := range selectors {
+		spec.rules = append(spec.rules, PathRule(selector, spec.strategy))
+	}
+
+	return spec
+}
+
+func (s tagSpec) selectors() []string {
+	selectors := make([]string, len(s.rules))
+	for i, rule := range s.rules {
+		selectors[i] = rule.selector
+	}
+
+	return selectors
+}
+
+func (s tagSpec) matchesEntry(key reflect.Value) bool {
+	if key.Kind() != reflect.String {
+		return false
+	}
+
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry, key.String()) {
+			return true
+		}
+	}
+
+	return false
+}
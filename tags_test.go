@@ -0,0 +1,180 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTagNames(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	tagValueMaps = map[string]map[string]string{}
+	defer SetTagNames("sensitive")
+
+	type RedactStruct struct {
+		F1 string `sensitive:"-"`
+		F2 string `mask:"-"`
+		F3 string `secret:"custom" sensitive:"-"`
+	}
+
+	SetCustomRedact("custom", "[CUSTOM]")
+	SetTagNames("secret", "mask")
+
+	obj := RedactStruct{
+		F1: "F1",
+		F2: "F2",
+		F3: "F3",
+	}
+
+	redacted := Redact(obj)
+	require.Equal(t, RedactStruct{F1: "F1", F3: "[CUSTOM]"}, redacted)
+}
+
+func TestMapTagValue(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+	tagValueMaps = map[string]map[string]string{}
+	defer SetTagNames("sensitive")
+
+	type RedactStruct struct {
+		F1 string `log:"-"`
+		F2 string `log:"name"`
+		F3 string `mask:"filled"`
+		F4 string `mask:"hash"`
+		F5 string `sensitive:"-"`
+	}
+
+	SetCustomRedact("filled", "****")
+	SetTagNames("sensitive", "log", "mask")
+	MapTagValue("log", "-", "-")
+	MapTagValue("mask", "*", "-")
+	MapTagValue("mask", "filled", "filled")
+
+	obj := RedactStruct{
+		F1: "F1",
+		F2: "F2",
+		F3: "F3",
+		F4: "F4",
+		F5: "F5",
+	}
+
+	redacted := Redact(obj)
+	require.Equal(t, RedactStruct{F2: "F2", F3: "****"}, redacted)
+}
+
+func TestMapTagOptions(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type (
+		Setting struct {
+			Value  string
+			Secret string `sensitive:"-"`
+		}
+
+		RedactStruct struct {
+			Values    map[string]string   `sensitive:"values"`
+			Keys      map[string]Setting  `sensitive:"keys,key"`
+			Headers   map[string][]string `sensitive:"entries=authorization|x-api-key"`
+			Settings  map[string]Setting  `sensitive:"entries=password,custom"`
+			IntKeys   map[int]string      `sensitive:"entries=1"`
+			NotAMap   string              `sensitive:"values"`
+			NilValues map[string]string   `sensitive:"values"`
+		}
+	)
+
+	SetCustomRedact("key", "[KEY]")
+	SetCustomRedact("custom", Setting{Value: "[CUSTOM]"})
+
+	obj := RedactStruct{
+		Values: map[string]string{
+			"a": "1",
+			"b": "2",
+		},
+		Keys: map[string]Setting{
+			"secret key": {
+				Value:  "value",
+				Secret: "secret",
+			},
+		},
+		Headers: map[string][]string{
+			"Authorization": {"Bearer token"},
+			"X-Api-Key":     {"key"},
+			"Accept":        {"application/json"},
+		},
+		Settings: map[string]Setting{
+			"Password": {Value: "password"},
+			"timeout": {
+				Value:  "10s",
+				Secret: "secret",
+			},
+		},
+		IntKeys: map[int]string{1: "1"},
+		NotAMap: "value",
+	}
+
+	redacted := Redact(obj)
+	require.Equal(t, RedactStruct{
+		Values: map[string]string{
+			"a": "",
+			"b": "",
+		},
+		Keys: map[string]Setting{
+			"[KEY]": {Value: "value"},
+		},
+		Headers: map[string][]string{
+			"Authorization": nil,
+			"X-Api-Key":     nil,
+			"Accept":        {"application/json"},
+		},
+		Settings: map[string]Setting{
+			"Password": {Value: "[CUSTOM]"},
+			"timeout":  {Value: "10s"},
+		},
+	}, redacted)
+
+	require.Equal(t, "1", obj.Values["a"])
+	require.Equal(t, "Bearer token", obj.Headers["Authorization"][0])
+}
+
+func TestEachTagOption(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	type (
+		Card struct {
+			Number string
+		}
+
+		RedactStruct struct {
+			Slice   []string     `sensitive:"each"`
+			Nested  [][]string   `sensitive:"each,masked"`
+			Array   [2]int       `sensitive:"each"`
+			PSlice  *[]*string   `sensitive:"each"`
+			Structs []Card       `sensitive:"each,card"`
+			Empty   []string     `sensitive:"each"`
+			ArrArr  [1][2]string `sensitive:"each,masked"`
+		}
+	)
+
+	SetCustomRedact("masked", "****")
+	SetCustomRedact("card", Card{Number: "****"})
+
+	obj := RedactStruct{
+		Slice:   []string{"a", "b", "c"},
+		Nested:  [][]string{{"a"}, {"b", "c"}},
+		Array:   [2]int{1, 2},
+		PSlice:  &[]*string{vToP("a"), vToP("b")},
+		Structs: []Card{{Number: "4111"}},
+		ArrArr:  [1][2]string{{"a", "b"}},
+	}
+
+	redacted := Redact(obj)
+	require.Equal(t, RedactStruct{
+		Slice:   []string{"", "", ""},
+		Nested:  [][]string{{"****"}, {"****", "****"}},
+		PSlice:  &[]*string{vToP(""), vToP("")},
+		Structs: []Card{{Number: "****"}},
+		ArrArr:  [1][2]string{{"****", "****"}},
+	}, redacted)
+
+	require.Equal(t, []string{"a", "b", "c"}, obj.Slice)
+}
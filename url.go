@@ -0,0 +1,209 @@
+package desensitivize
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// redactedURLValue replaces passwords, sensitive query parameters and path
+// segments in URLs.
+const redactedURLValue = "REDACTED"
+
+const urlStrategy = "url"
+
+var (
+	urlType       = reflect.TypeOf(url.URL{})
+	urlValuesType = reflect.TypeOf(url.Values{})
+
+	sensitiveQueryParams []*regexp.Regexp
+	sensitivePathParts   []*regexp.Regexp
+)
+
+func init() {
+	SetSensitiveQueryParams(
+		"token", "*_token", "password", "passwd", "pwd", "secret", "*_secret",
+		"api_key", "apikey", "key", "signature", "sig", "auth",
+	)
+
+	typeHandlers[urlType] = (*redactor).handleURL
+	typeHandlers[urlValuesType] = (*redactor).handleURLValues
+}
+
+// SetSensitiveQueryParams replaces the names of the query parameters whose
+// values are redacted in URLs and url.Values. Names are case-insensitive and
+// may contain * and ? wildcards like key rules.
+func SetSensitiveQueryParams(names ...string) {
+	sensitiveQueryParams = make([]*regexp.Regexp, len(names))
+	for i, name := range names {
+		sensitiveQueryParams[i] = globPattern(name)
+	}
+}
+
+// SetSensitivePathSegments replaces the patterns of URL path segments which
+// are redacted, e.g. ^[0-9a-f]{32}$ for tokens in reset links. A segment is
+// redacted when a pattern matches it.
+func SetSensitivePathSegments(patterns ...*regexp.Regexp) {
+	sensitivePathParts = append([]*regexp.Regexp(nil), patterns...)
+}
+
+// RedactURL redacts the password, the sensitive query parameters and the
+// sensitive path segments of a URL, keeping the rest readable. Strings which
+// don't parse as URLs are redacted as a whole.
+func RedactURL(rawURL string) string {
+	redacted, err := redactURLString(rawURL)
+	if err != nil {
+		return redactedURLValue
+	}
+
+	return redacted
+}
+
+func redactURLString(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	redacted, changed := redactURL(*parsed)
+	if !changed {
+		return rawURL, nil
+	}
+
+	return redacted.String(), nil
+}
+
+func urlStep(s, _ string) (string, error) {
+	return redactURLString(s)
+}
+
+// redactURL returns u with its sensitive parts replaced and whether any
+// were.
+func redactURL(u url.URL) (url.URL, bool) {
+	changed := false
+
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redactedURLValue)
+			changed = true
+		}
+	}
+
+	if query, queryChanged := redactQuery(u.RawQuery); queryChanged {
+		u.RawQuery = query
+		changed = true
+	}
+
+	if u.Opaque == "" && len(sensitivePathParts) > 0 {
+		segments := strings.Split(u.Path, "/")
+		pathChanged := false
+
+		for i, segment := range segments {
+			if segment != "" && sensitivePathSegment(segment) {
+				segments[i] = redactedURLValue
+				pathChanged = true
+			}
+		}
+
+		if pathChanged {
+			u.Path = strings.Join(segments, "/")
+			u.RawPath = ""
+			changed = true
+		}
+	}
+
+	return u, changed
+}
+
+// redactQuery redacts the values of sensitive parameters in a raw query,
+// keeping the order and encoding of the others.
+func redactQuery(query string) (string, bool) {
+	if query == "" {
+		return query, false
+	}
+
+	params := strings.Split(query, "&")
+	changed := false
+
+	for i, param := range params {
+		key, value, hasValue := strings.Cut(param, "=")
+		if !hasValue || value == "" {
+			continue
+		}
+
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+
+		if sensitiveQueryParam(name) {
+			params[i] = key + "=" + redactedURLValue
+			changed = true
+		}
+	}
+
+	return strings.Join(params, "&"), changed
+}
+
+func sensitiveQueryParam(name string) bool {
+	for _, pattern := range sensitiveQueryParams {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sensitivePathSegment(segment string) bool {
+	for _, pattern := range sensitivePathParts {
+		if pattern.MatchString(segment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *redactor) handleURL(obj reflect.Value) (reflect.Value, bool) {
+	redacted, changed := redactURL(obj.Interface().(url.URL))
+	if !changed {
+		return obj, false
+	}
+
+	r.record(urlType, urlStrategy)
+	return reflect.ValueOf(redacted), true
+}
+
+func (r *redactor) handleURLValues(obj reflect.Value) (reflect.Value, bool) {
+	values := obj.Interface().(url.Values)
+	redacted := values
+	changed := false
+
+	for name, params := range values {
+		if len(params) == 0 || !sensitiveQueryParam(name) {
+			continue
+		}
+
+		if !changed && !r.inPlace {
+			redacted = make(url.Values, len(values))
+			for name, params := range values {
+				redacted[name] = params
+			}
+		}
+		changed = true
+
+		r.enterKey(reflect.ValueOf(name))
+		r.record(reflect.TypeOf(params), urlStrategy)
+		r.leave()
+
+		replaced := make([]string, len(params))
+		for i := range replaced {
+			replaced[i] = redactedURLValue
+		}
+		redacted[name] = replaced
+	}
+
+	return reflect.ValueOf(redacted), changed
+}
@@ -0,0 +1,42 @@
+package desensitivize
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// MarshalXML encodes v like xml.Marshal would encode Redact(v), honoring
+// xml tags. Like TryRedact it fails when the redaction does.
+func MarshalXML(v any) ([]byte, error) {
+	redacted, _, err := TryRedact(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return xml.Marshal(redacted)
+}
+
+// XMLEncoder writes redacted XML values to a stream.
+type XMLEncoder struct {
+	enc *xml.Encoder
+}
+
+func NewXMLEncoder(w io.Writer) *XMLEncoder {
+	return &XMLEncoder{enc: xml.NewEncoder(w)}
+}
+
+// Indent sets the indentation like xml.Encoder.Indent.
+func (enc *XMLEncoder) Indent(prefix, indent string) {
+	enc.enc.Indent(prefix, indent)
+}
+
+// Encode writes the redacted XML encoding of v, like xml.Encoder.Encode.
+// Nothing is written when the redaction fails.
+func (enc *XMLEncoder) Encode(v any) error {
+	redacted, _, err := TryRedact(v)
+	if err != nil {
+		return err
+	}
+
+	return enc.enc.Encode(redacted)
+}
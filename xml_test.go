@@ -0,0 +1,71 @@
+package desensitivize
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type xmlLogin struct {
+	XMLName  xml.Name `xml:"login"`
+	User     string   `xml:"user,attr"`
+	Token    string   `xml:"token,attr" sensitive:"mask:last=2"`
+	Password string   `xml:"credentials>password" sensitive:"-"`
+	Cards    []string `xml:"card" sensitive:"each,mask:last=4"`
+}
+
+func TestMarshalXML(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	login := xmlLogin{
+		User:     "john",
+		Token:    "abcdef",
+		Password: "hunter2",
+		Cards:    []string{"4111111111111111"},
+	}
+
+	data, err := MarshalXML(login)
+	require.NoError(t, err)
+	require.Equal(t, `<login user="john" token="****ef"><credentials><password></password></credentials>`+
+		`<card>************1111</card></login>`, string(data))
+	require.Equal(t, "hunter2", login.Password)
+
+	expected, err := xml.Marshal(Redact(login))
+	require.NoError(t, err)
+	require.Equal(t, expected, data)
+
+	data, err = MarshalXML(&login)
+	require.NoError(t, err)
+	require.Equal(t, expected, data)
+
+	_, err = MarshalXML(struct {
+		Name string `sensitive:"trim|unknown"`
+	}{Name: "x"})
+	require.EqualError(t, err, `desensitivize: unknown step "unknown" in "trim|unknown"`)
+}
+
+func TestXMLEncoder(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	var out bytes.Buffer
+	enc := NewXMLEncoder(&out)
+	enc.Indent("", " ")
+
+	require.NoError(t, enc.Encode(xmlLogin{User: "john", Password: "hunter2"}))
+	require.Equal(t, `<login user="john" token="">
+ <credentials>
+  <password></password>
+ </credentials>
+</login>`, out.String())
+
+	out.Reset()
+	err := enc.Encode(struct {
+		XMLName xml.Name `xml:"a"`
+		Name    string   `sensitive:"trim|unknown"`
+	}{Name: "x"})
+	require.EqualError(t, err, `desensitivize: unknown step "unknown" in "trim|unknown"`)
+	require.Empty(t, out.String())
+}
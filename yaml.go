@@ -0,0 +1,14 @@
+package desensitivize
+
+import "gopkg.in/yaml.v3"
+
+// MarshalYAML encodes v like yaml.Marshal would encode Redact(v), honoring
+// yaml tags. Like TryRedact it fails when the redaction does.
+func MarshalYAML(v any) ([]byte, error) {
+	redacted, _, err := TryRedact(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(redacted)
+}
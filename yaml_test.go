@@ -0,0 +1,47 @@
+package desensitivize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type yamlConfig struct {
+	Name     string            `yaml:"name"`
+	Password string            `yaml:"db_password" sensitive:"-"`
+	Token    string            `yaml:"token,omitempty" sensitive:"mask:last=2"`
+	Headers  map[string]string `yaml:"headers" sensitive:"entries=Authorization"`
+}
+
+func TestMarshalYAML(t *testing.T) {
+	customRedacts = map[reflect.Type]redactMeta{}
+
+	config := yamlConfig{
+		Name:     "billing",
+		Password: "hunter2",
+		Token:    "abcdef",
+		Headers:  map[string]string{"Authorization": "Bearer x", "Accept": "*/*"},
+	}
+
+	data, err := MarshalYAML(config)
+	require.NoError(t, err)
+	require.Equal(t, `name: billing
+db_password: ""
+token: '****ef'
+headers:
+    Accept: '*/*'
+    Authorization: ""
+`, string(data))
+	require.Equal(t, "hunter2", config.Password)
+
+	expected, err := yaml.Marshal(Redact(config))
+	require.NoError(t, err)
+	require.Equal(t, expected, data)
+
+	_, err = MarshalYAML(struct {
+		Name string `sensitive:"trim|unknown"`
+	}{Name: "x"})
+	require.EqualError(t, err, `desensitivize: unknown step "unknown" in "trim|unknown"`)
+}